@@ -1,10 +1,10 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
-	"math/rand"
-	"time"
 
 	"github.com/miracl/core/go/core"
 	"github.com/miracl/core/go/core/BLS24479"
@@ -49,8 +49,19 @@ type subset struct {
 // ----------- Package Scope Variables
 var rng *core.RAND
 
+var errRevoked = errors.New("ERROR: d = 0, your ID is part of the revoked set!")
+var errLength = errors.New("ERROR: ID, CL, RL and secret key have different lengths")
+
 // Setup Algorithm (l,lambda) -> PK,MK
 func setup(l int) (pubKey *pk, mk *BLS24479.ECP) {
+	// setupCtx can only fail if its context is cancelled
+	pubKey, mk, _ = setupCtx(context.Background(), l, nil)
+	return pubKey, mk
+}
+
+// Setup Algorithm with cancellation and progress reporting
+// progress (may be nil) is called after each of the 4l group elements of Setup 4 is done
+func setupCtx(ctx context.Context, l int, progress func(done, total int)) (pubKey *pk, mk *BLS24479.ECP, err error) {
 	// ----------- Setup 1
 	// Generate bilinear groups of order p (already done once you chose the curve)
 	p := BLS24479.NewBIGints(BLS24479.Modulus)
@@ -66,69 +77,63 @@ func setup(l int) (pubKey *pk, mk *BLS24479.ECP) {
 	// Select random exponent alpha in Zp
 	// q needs to be modulus to ensure valid new ECP
 	alpha := BLS24479.Randomnum(q, rng)
+	defer wipeBIG(alpha)
 
 	// ----------- Setup 4
 	// Select random group elements
-	// h1,0 ... hl,0 and h1,1 ... hl,1 in two slices for readability
+	// h0
 	h0Rand := BLS24479.Randomnum(q, rng)
-	h0 := BLS24479.G1mul(g1, h0Rand)
-
-	hrands0 := make([]*BLS24479.BIG, l)    // slice for random numbers h1,0 ... hl,0
-	helements0 := make([]*BLS24479.ECP, l) // Slice for resulting random group elements of G1
-	for i := 0; i < l; i++ {
-		hrands0[i] = BLS24479.Randomnum(q, rng)
-		helements0[i] = BLS24479.G1mul(g1, hrands0[i])
-	}
-
-	hrands1 := make([]*BLS24479.BIG, l)    // slice for random numbers h1,1 ... hl,1
-	helements1 := make([]*BLS24479.ECP, l) // Slice for resulting random group elements of G1
-	for i := 0; i < l; i++ {
-		hrands1[i] = BLS24479.Randomnum(q, rng)
-		helements1[i] = BLS24479.G1mul(g1, hrands1[i])
-	}
+	h0 := g1mul(g1, h0Rand)
+	wipeBIG(h0Rand)
 
 	// k0, k1,0 ... kl,1
 	k0Rand := BLS24479.Randomnum(q, rng)
-	k0 := BLS24479.G1mul(g1, k0Rand)
+	k0 := g1mul(g1, k0Rand)
+	wipeBIG(k0Rand)
 
-	krands0 := make([]*BLS24479.BIG, l)    // slice for random numbers k1,0 ... kl,0
-	kelements0 := make([]*BLS24479.ECP, l) // Slice for resulting random group elements of G1
-	for i := 0; i < l; i++ {
-		krands0[i] = BLS24479.Randomnum(q, rng)
-		kelements0[i] = BLS24479.G1mul(g1, krands0[i])
-	}
-
-	krands1 := make([]*BLS24479.BIG, l)    // slice for random numbers k1,1 ... kl,1
-	kelements1 := make([]*BLS24479.ECP, l) // Slice for resulting random group elements of G1
-	for i := 0; i < l; i++ {
-		krands1[i] = BLS24479.Randomnum(q, rng)
-		kelements1[i] = BLS24479.G1mul(g1, krands1[i])
+	// h1,0 ... hl,0, h1,1 ... hl,1, k1,0 ... kl,0 and k1,1 ... kl,1 are computed concurrently
+	elements, err := genElements(ctx, g1, 4, l, progress)
+	if err != nil {
+		return nil, nil, err
 	}
+	helements0 := elements[0]
+	helements1 := elements[1]
+	kelements0 := elements[2]
+	kelements1 := elements[3]
 
 	// ----------- Setup 5
 	// Return master key MK = g1^alpha
-	mk = BLS24479.G1mul(g1, alpha)
+	mk = g1mul(g1, alpha)
 
 	// ----------- Setup 6
 	// compute pairing Omega = e(g1,g2)^alpha
-	omega := BLS24479.Ate(g2, g1)
-	omega = BLS24479.Fexp(omega)
-	omega = omega.Pow(alpha)
+	omega := ate(g2, g1)
+	omega = fexp(omega)
+	omega = gtpow(omega, alpha)
 	// Return Public Key / Public Parameters
 	pubKey = &pk{p, g1, g2, h0, k0, helements0, helements1, kelements0, kelements1, omega}
 
-	return pubKey, mk
+	return pubKey, mk, nil
 }
 
 // KeyGen Algorithm (user's ID, MK, PK) -> SK_ID
 func keyGen(id string, mk *BLS24479.ECP, pubKey *pk) (secKey *sk) {
+	return keyGenWith(id, mk, pubKey, computeHID(id, pubKey), rng)
+}
+
+// KeyGen Algorithm with precomputed h_ID = h0 * h1,id1 * ... * hl,idl
+// random exponents are taken from rnd, so concurrent calls need separate generators
+func keyGenWith(id string, mk *BLS24479.ECP, pubKey *pk, hID *BLS24479.ECP, rnd *core.RAND) (secKey *sk) {
 
 	q := BLS24479.NewBIGints(BLS24479.CURVE_Order)
 	l := len(id)
 	// ----------- KeyGen 1
 	// 1. Select two random exponents alpha_omega and r in Zp
-	alphaOmega := BLS24479.Randomnum(q, rng)
-	r := BLS24479.Randomnum(q, rng)
+	alphaOmega := BLS24479.Randomnum(q, rnd)
+	r := BLS24479.Randomnum(q, rnd)
+	// exponents and intermediate values of x0 are wiped once the key is done
+	defer wipeBIG(alphaOmega)
+	defer wipeBIG(r)
 
 	// ----------- KeyGen 2
 	// Create private key SK_ID
@@ -137,41 +142,33 @@ func keyGen(id string, mk *BLS24479.ECP, pubKey *pk) (secKey *sk) {
 	//// g1^(alpha-alphaOmega) = mk / mk2 where mk2 = g1^alphaOmega
 	mk1 := BLS24479.NewECP()
 	mk1.Copy(mk)
-	mk2 := BLS24479.G1mul(pubKey.g1, alphaOmega)
+	mk2 := g1mul(pubKey.g1, alphaOmega)
 	g1AlphaOmega := BLS24479.NewECP()
 	g1AlphaOmega.Copy(mk2) // We need a an unchanged version of mk2 for later
 	mk2.Neg()              // compute mk2^-1
-	mk1.Add(mk2)           // mk * mk2^-1
+	g1add(mk1, mk2)        // mk * mk2^-1
+	defer wipeECP(mk1)
+	defer wipeECP(mk2)
+	defer wipeECP(g1AlphaOmega)
 
-	hID := BLS24479.NewECP()
-	hID.Copy(pubKey.h0) // deep copy of ECP via ECP.Copy() method
-	for i := 0; i < l; i++ {
-		if string(id[i]) == "0" {
-			hID.Add(pubKey.helements0[i])
-		} else if string(id[i]) == "1" {
-			hID.Add(pubKey.helements1[i])
-		} else {
-			fmt.Println("ID could not be read")
-		}
-	}
-	hExp := BLS24479.G1mul(hID, r)
-	hExp.Add(mk1)
+	hExp := g1mul(hID, r)
+	g1add(hExp, mk1)
 	x0 := hExp
 
 	// ---x1 - xl
 	xelements := make([]*BLS24479.ECP, l)
 	for i := 0; i < l; i++ {
 		if string(id[i]) == "0" {
-			xelements[i] = BLS24479.G1mul(pubKey.helements1[i], r)
+			xelements[i] = g1mul(pubKey.helements1[i], r)
 		} else if string(id[i]) == "1" {
-			xelements[i] = BLS24479.G1mul(pubKey.helements0[i], r)
+			xelements[i] = g1mul(pubKey.helements0[i], r)
 		} else {
 			fmt.Println("ID could not be read")
 		}
 	}
 
 	// -- y0
-	y0 := BLS24479.G1mul(pubKey.k0, r)
+	y0 := g1mul(pubKey.k0, r)
 
 	// -- y1...y2l
 	//// two slices of odd and even for readability
@@ -179,91 +176,156 @@ func keyGen(id string, mk *BLS24479.ECP, pubKey *pk) (secKey *sk) {
 	yEven := make([]*BLS24479.ECP, l)
 	for i := 0; i < l; i++ {
 		if string(id[i]) == "0" {
-			temp := BLS24479.G1mul(pubKey.kelements1[i], r)
-			temp.Add(g1AlphaOmega)
+			temp := g1mul(pubKey.kelements1[i], r)
+			g1add(temp, g1AlphaOmega)
 			yOdd[i] = temp
-			yEven[i] = BLS24479.G1mul(pubKey.kelements0[i], r)
+			yEven[i] = g1mul(pubKey.kelements0[i], r)
 		} else if string(id[i]) == "1" {
-			temp := BLS24479.G1mul(pubKey.kelements0[i], r)
-			temp.Add(g1AlphaOmega)
+			temp := g1mul(pubKey.kelements0[i], r)
+			g1add(temp, g1AlphaOmega)
 			yOdd[i] = temp
-			yEven[i] = BLS24479.G1mul(pubKey.kelements1[i], r)
+			yEven[i] = g1mul(pubKey.kelements1[i], r)
 		} else {
 			fmt.Println("ID could not be read")
 		}
 	}
 
 	// z
-	z := BLS24479.G2mul(pubKey.g2, r)
+	z := g2mul(pubKey.g2, r)
 
 	// return private key
 	secKey = &sk{x0, xelements, y0, yEven, yOdd, z}
 	return secKey
 }
 
+// compute h_ID = h0 * h1,id1 * ... * hl,idl
+func computeHID(id string, pubKey *pk) *BLS24479.ECP {
+	hID := BLS24479.NewECP()
+	hID.Copy(pubKey.h0) // deep copy of ECP via ECP.Copy() method
+	for i := 0; i < len(id); i++ {
+		if string(id[i]) == "0" {
+			g1add(hID, pubKey.helements0[i])
+		} else if string(id[i]) == "1" {
+			g1add(hID, pubKey.helements1[i])
+		} else {
+			fmt.Println("ID could not be read")
+		}
+	}
+	return hID
+}
+
 // Encrypt(S=(CL,RL), PK, and message M) -> Header HdrS)
+// M has to be a uniformly random secret element of GT, as the key confirmation
+// value lets anyone test guesses of M (see confirm.go). Use encapsulate and derive
+// the key for the actual data from M.
 func encrypt(s *subset, pubKey *pk, message *BLS24479.FP24) (cipher *hdr) {
+	return encryptWith(aggregateH(s.cl, pubKey), aggregateK(s.rl, pubKey), pubKey, message)
+}
+
+// Encapsulate(S=(CL,RL), PK) -> Header HdrS and a fresh random M
+func encapsulate(s *subset, pubKey *pk) (cipher *hdr, message *BLS24479.FP24) {
+	message = randomGT(pubKey)
+	return encrypt(s, pubKey, message), message
+}
+
+// Encrypt with already aggregated H(CL) and K(RL)
+func encryptWith(hcl *BLS24479.ECP, krl *BLS24479.ECP, pubKey *pk, message *BLS24479.FP24) (cipher *hdr) {
+	return encryptMul(func(t *BLS24479.BIG) (*BLS24479.ECP, *BLS24479.ECP) {
+		return g1mul(hcl, t), g1mul(krl, t)
+	}, pubKey, message)
+}
+
+// Encrypt with c2 = H(CL)^t and c3 = K(RL)^t computed by mulHK, e.g. from fixed-base tables
+func encryptMul(mulHK func(t *BLS24479.BIG) (c2 *BLS24479.ECP, c3 *BLS24479.ECP), pubKey *pk, message *BLS24479.FP24) (cipher *hdr) {
 
 	q := BLS24479.NewBIGints(BLS24479.CURVE_Order)
-	l := len(s.cl)
 
 	// ----------- Encrypt 1
 	// Select random exponent t in Zp
 	t := BLS24479.Randomnum(q, rng)
+	defer wipeBIG(t)
 
 	// ----------- Encrypt 2
 	// Return ciphertext Hdr = (C0, C1, C2 C3)
 
 	// C0 = omega^t * M (both GT elements)
-	c0 := pubKey.omega.Pow(t)
-	c0.Mul(message)
+	c0 := gtpow(pubKey.omega, t)
+	gtmul(c0, message)
 
 	// c1 = g2^t
-	c1 := BLS24479.G2mul(pubKey.g2, t)
+	c1 := g2mul(pubKey.g2, t)
+
+	// c2 = H(CL)^t, c3 = K(RL)^t
+	c2, c3 := mulHK(t)
+
+	cipher = &hdr{c0, c1, c2, c3}
+	return cipher
+}
 
-	// c2 = H(CL)^t
+// compute H(CL) = h0 * product of h_i,CLi (h_i,0 * h_i,1 for wildcards)
+func aggregateH(cl string, pubKey *pk) *BLS24479.ECP {
 	hcl := BLS24479.NewECP()
 	hcl.Copy(pubKey.h0)
-	for i := 0; i < l; i++ {
-		if string(s.cl[i]) == "0" {
-			hcl.Add(pubKey.helements0[i])
-		} else if string(s.cl[i]) == "1" {
-			hcl.Add(pubKey.helements1[i])
-		} else if string(s.cl[i]) == "*" {
+	for i := 0; i < len(cl); i++ {
+		if string(cl[i]) == "0" {
+			g1add(hcl, pubKey.helements0[i])
+		} else if string(cl[i]) == "1" {
+			g1add(hcl, pubKey.helements1[i])
+		} else if string(cl[i]) == "*" {
 			hProd := BLS24479.NewECP()
 			hProd.Copy(pubKey.helements0[i])
-			hProd.Add(pubKey.helements1[i])
-			hcl.Add(hProd)
+			g1add(hProd, pubKey.helements1[i])
+			g1add(hcl, hProd)
 		} else {
 			fmt.Println("CL could not be read")
 		}
 	}
-	c2 := BLS24479.G1mul(hcl, t)
+	return hcl
+}
 
-	// c3 = K(RL)^t
+// compute K(RL) = k0 * product of k_i,RLi (nothing for wildcards)
+func aggregateK(rl string, pubKey *pk) *BLS24479.ECP {
 	krl := BLS24479.NewECP()
 	krl.Copy(pubKey.k0)
-	for i := 0; i < l; i++ {
-		if string(s.rl[i]) == "0" {
-			krl.Add(pubKey.kelements0[i])
-		} else if string(s.rl[i]) == "1" {
-			krl.Add(pubKey.kelements1[i])
-		} else if string(s.rl[i]) == "*" {
+	for i := 0; i < len(rl); i++ {
+		if string(rl[i]) == "0" {
+			g1add(krl, pubKey.kelements0[i])
+		} else if string(rl[i]) == "1" {
+			g1add(krl, pubKey.kelements1[i])
+		} else if string(rl[i]) == "*" {
 			// * - Do nothing
 		} else {
 			fmt.Println("RL could not be read")
 		}
 	}
-	c3 := BLS24479.G1mul(krl, t)
-
-	cipher = &hdr{c0, c1, c2, c3}
-	return cipher
+	return krl
 }
 
 // Decrypt(S=(CL,RL),ID,SK_ID,HdrS) -> M or error
+// The header is validated on every call, the secret key only once where it is
+// created or loaded: keyGen builds valid keys, skFromBytes and newDecryptor
+// validate them, so any other key has to pass secKey.Validate() first.
+// A device that is not covered gets a random looking M and no error, openKeys
+// of the derived data key detects that, see confirm.go.
 func decrypt(s *subset, id string, secKey *sk, cipher *hdr) (mes *BLS24479.FP24, err error) {
+	if err := cipher.Validate(); err != nil {
+		return nil, err
+	}
+	xy, dExp, err := decryptKey(s, id, secKey)
+	if err != nil {
+		return nil, err
+	}
+	return decryptWith(xy, dExp, secKey.z, cipher), nil
+}
+
+// Decrypt 1 - 4 without the header: x' * y'^(d^-1) and d^-1
+// both only depend on the subset, so they can be reused for every header sent to it
+func decryptKey(s *subset, id string, secKey *sk) (xy *BLS24479.ECP, dExp *BLS24479.BIG, err error) {
 
 	l := len(id)
+	if len(s.cl) != l || len(s.rl) != l || len(secKey.xelements) != l {
+		return nil, nil, errLength
+	}
 
 	// ----------- Decrypt 1
 	// compute P = bits that are different from revoked list
@@ -290,59 +352,63 @@ func decrypt(s *subset, id string, secKey *sk, cipher *hdr) (mes *BLS24479.FP24,
 
 	// ----------- Decrypt 4
 	// if d > 0, decrypt message, else return error
+	if d == 0 {
+		return nil, nil, errRevoked
+	}
 
-	if d > 0 {
-		// compute x'
-		xAp := BLS24479.NewECP()
-		xAp.Copy(secKey.x0)
-		for i := 0; i < l; i++ {
-			if string(s.cl[i]) == "*" {
-				xAp.Add(secKey.xelements[i])
-			}
+	// compute x'
+	xAp := BLS24479.NewECP()
+	xAp.Copy(secKey.x0)
+	for i := 0; i < l; i++ {
+		if string(s.cl[i]) == "*" {
+			g1add(xAp, secKey.xelements[i])
 		}
+	}
 
-		// compute y'
-		yAp := BLS24479.NewECP()
-		yAp.Copy(secKey.y0)
-		for i := 1; i < l+1; i++ {
-			if contains(pRl, i) {
-				yAp.Add(secKey.yOdd[i-1])
-			}
+	// compute y'
+	yAp := BLS24479.NewECP()
+	yAp.Copy(secKey.y0)
+	for i := 1; i < l+1; i++ {
+		if contains(pRl, i) {
+			g1add(yAp, secKey.yOdd[i-1])
 		}
-		for i := 1; i < l+1; i++ {
-			if contains(qRl, i) {
-				yAp.Add(secKey.yEven[i-1])
-			}
+	}
+	for i := 1; i < l+1; i++ {
+		if contains(qRl, i) {
+			g1add(yAp, secKey.yEven[i-1])
 		}
-		dExp := BLS24479.NewBIGint(d)
-		dExp.Invmodp(BLS24479.NewBIGints(BLS24479.CURVE_Order)) // d^-1
-		yAp = BLS24479.G1mul(yAp, dExp)
+	}
+	dExp = BLS24479.NewBIGint(d)
+	dExp.Invmodp(BLS24479.NewBIGints(BLS24479.CURVE_Order)) // d^-1
+	yAp = g1mul(yAp, dExp)
 
-		// decrypt message: m = c0 * e(x'*y', C1)^-1 * e(C2*C3^(d-1), z)
-		xAp2 := BLS24479.NewECP()
-		xAp2.Copy(xAp)
-		xAp2.Add(yAp) // x' * y'
+	xy = BLS24479.NewECP()
+	xy.Copy(xAp)
+	g1add(xy, yAp) // x' * y'
 
-		e1 := BLS24479.Ate(cipher.c1, xAp2)
-		e1 = BLS24479.Fexp(e1)
-		e1.Inverse() // e(x'*y', C1)^-1
+	return xy, dExp, nil
+}
 
-		c3Ap := BLS24479.G1mul(cipher.c3, dExp)
-		c2Ap := BLS24479.NewECP()
-		c2Ap.Copy(cipher.c2)
-		c2Ap.Add(c3Ap) // C2*C3^(d-1)
+// Decrypt the header with x' * y' and d^-1 from decryptKey
+func decryptWith(xy *BLS24479.ECP, dExp *BLS24479.BIG, z *BLS24479.ECP4, cipher *hdr) (mes *BLS24479.FP24) {
+	// decrypt message: m = c0 * e(x'*y', C1)^-1 * e(C2*C3^(d-1), z)
+	e1 := ate(cipher.c1, xy)
+	e1 = fexp(e1)
+	e1.Inverse() // e(x'*y', C1)^-1
 
-		e2 := BLS24479.Ate(secKey.z, c2Ap)
-		e2 = BLS24479.Fexp(e2) // e(C2*C3^(d-1), z)
+	c3Ap := g1mul(cipher.c3, dExp)
+	c2Ap := BLS24479.NewECP()
+	c2Ap.Copy(cipher.c2)
+	g1add(c2Ap, c3Ap) // C2*C3^(d-1)
 
-		mes = BLS24479.NewFP24copy(cipher.c0)
-		mes.Mul(e1)
-		mes.Mul(e2) // m
+	e2 := ate(z, c2Ap)
+	e2 = fexp(e2) // e(C2*C3^(d-1), z)
 
-	} else {
-		err = errors.New("ERROR: d = 0, your ID is part of the revoked set!")
-	}
-	return mes, err
+	mes = BLS24479.NewFP24copy(cipher.c0)
+	gtmul(mes, e1)
+	gtmul(mes, e2) // m
+
+	return mes
 }
 
 //-----Helper Functions
@@ -368,21 +434,16 @@ func getIndex(s int, sl []int) int {
 
 // Initialise the Random Number Generator
 // call only once at beginning of program!
+// The seed comes from the operating system, without it no key can be generated safely.
 func initRNG() {
 	var raw [128]byte
-
-	// non-fixed seed
-	seed := rand.NewSource(time.Now().UnixNano())
-	r := rand.New(seed)
+	if _, err := rand.Read(raw[:]); err != nil {
+		panic("ERROR: could not seed the random number generator: " + err.Error())
+	}
 
 	// rng from MIRACL Core Rand.go
 	rng = core.NewRAND()
 	rng.Clean()
-
-	// use non-fixed seed and generator from math/rand to create seed for rng
-	for i := 0; i < 128; i++ {
-		raw[i] = byte(r.Intn(255))
-	}
-
 	rng.Seed(128, raw[:]) // seed rng
+	wipeBytes(raw[:])
 }
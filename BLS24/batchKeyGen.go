@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"github.com/miracl/core/go/core"
+	"github.com/miracl/core/go/core/BLS24479"
+)
+
+// ----------- Structs
+
+// result of KeyGen for a single ID of a batch
+// either secKey or err is set
+type keyResult struct {
+	id     string
+	secKey *sk
+	err    error
+}
+
+// keySink receives the results of keyGenBatch
+// put is never called concurrently, returning an error aborts the batch
+type keySink interface {
+	put(res *keyResult) error
+}
+
+// callback as keySink
+type keySinkFunc func(res *keyResult) error
+
+func (f keySinkFunc) put(res *keyResult) error {
+	return f(res)
+}
+
+// channel as keySink
+type chanSink chan<- *keyResult
+
+func (c chanSink) put(res *keyResult) error {
+	c <- res
+	return nil
+}
+
+// keySink writing one JSON object per line
+type fileSink struct {
+	enc *json.Encoder
+}
+
+// JSON representation of a secret key
+type skJSON struct {
+	X0        string
+	Xelements []string
+	Y0        string
+	YEven     []string
+	YOdd      []string
+	Z         string
+}
+
+// JSON representation of one line written by fileSink
+type keyResultJSON struct {
+	ID    string
+	Error string  `json:",omitempty"`
+	SK    *skJSON `json:",omitempty"`
+}
+
+func newFileSink(w io.Writer) *fileSink {
+	return &fileSink{enc: json.NewEncoder(w)}
+}
+
+func (f *fileSink) put(res *keyResult) error {
+	line := &keyResultJSON{ID: res.id}
+	if res.err != nil {
+		line.Error = res.err.Error()
+	} else {
+		line.SK = skToJSON(res.secKey)
+	}
+	return f.enc.Encode(line)
+}
+
+// job for a keyGenBatch goroutine
+type keyJob struct {
+	id  string
+	hID *BLS24479.ECP
+}
+
+// Batch KeyGen Algorithm (IDs, MK, PK) -> SK_ID for every ID
+// Results are passed to sink as soon as they are ready, so not in the order of ids.
+// Invalid IDs are reported to sink with an error, the rest of the batch carries on.
+// The returned error is only set if sink failed or ctx was cancelled.
+func keyGenBatch(ctx context.Context, ids []string, mk *BLS24479.ECP, pubKey *pk, sink keySink) error {
+	l := len(pubKey.helements0)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// reject invalid IDs straight away, sort the others so that IDs with common prefixes follow each other
+	valid := []string{}
+	for _, id := range ids {
+		if err := checkID(id, l); err != nil {
+			if err := sink.put(&keyResult{id: id, err: err}); err != nil {
+				return err
+			}
+			continue
+		}
+		valid = append(valid, id)
+	}
+	sort.Strings(valid)
+	if len(valid) == 0 {
+		return nil
+	}
+
+	w := numWorkers
+	if w > len(valid) {
+		w = len(valid)
+	}
+	if w < 1 {
+		w = 1
+	}
+
+	// seed all streams up front, rng itself is only used from this goroutine
+	streams := make([]*core.RAND, w)
+	for i := 0; i < w; i++ {
+		streams[i] = newRandStream()
+	}
+
+	jobs := make(chan *keyJob)
+	results := make(chan *keyResult)
+	var wg sync.WaitGroup
+	for i := 0; i < w; i++ {
+		wg.Add(1)
+		go func(stream *core.RAND) {
+			defer wg.Done()
+			for job := range jobs {
+				secKey := keyGenWith(job.id, mk, pubKey, job.hID, stream)
+				select {
+				case results <- &keyResult{id: job.id, secKey: secKey}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(streams[i])
+	}
+
+	// h_ID is shared work: prefix[i] = h0 * h1,id1 * ... * hi,idi is kept from the previous ID
+	// so only the positions after the common prefix have to be added again
+	go func() {
+		defer close(jobs)
+		prefix := make([]*BLS24479.ECP, l+1)
+		prefix[0] = pubKey.h0
+		prev := ""
+		for _, id := range valid {
+			c := commonPrefix(prev, id)
+			for i := c; i < l; i++ {
+				next := BLS24479.NewECP()
+				next.Copy(prefix[i])
+				if string(id[i]) == "0" {
+					g1add(next, pubKey.helements0[i])
+				} else {
+					g1add(next, pubKey.helements1[i])
+				}
+				prefix[i+1] = next
+			}
+			prev = id
+
+			hID := BLS24479.NewECP()
+			hID.Copy(prefix[l])
+			select {
+			case jobs <- &keyJob{id: id, hID: hID}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for res := range results {
+		if err := sink.put(res); err != nil {
+			cancel()
+			for range results {
+				// drain so the goroutines can finish
+			}
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// check that id is a binary string of length l
+func checkID(id string, l int) error {
+	if len(id) != l {
+		return fmt.Errorf("ID %q has length %d, expected %d", id, len(id), l)
+	}
+	for i := 0; i < l; i++ {
+		if id[i] != '0' && id[i] != '1' {
+			return errors.New("ID could not be read: " + id)
+		}
+	}
+	return nil
+}
+
+// length of the common prefix of a and b
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// function to write SK to file
+func skToFile(secKey *sk) {
+	skJ := skToJSON(secKey)
+
+	file, _ := json.Marshal(skJ)
+	_ = ioutil.WriteFile("sk.json", file, 0777)
+}
+
+// function to turn SK into its JSON representation
+func skToJSON(secKey *sk) *skJSON {
+	return &skJSON{
+		X0:        secKey.x0.ToString(),
+		Xelements: toStrArr(secKey.xelements),
+		Y0:        secKey.y0.ToString(),
+		YEven:     toStrArr(secKey.yEven),
+		YOdd:      toStrArr(secKey.yOdd),
+		Z:         secKey.z.ToString(),
+	}
+}
+
+// function to turn ECP slice to String slice
+// helper function for skToFile()
+func toStrArr(a []*BLS24479.ECP) []string {
+	result := make([]string, len(a))
+
+	for i := 0; i < len(a); i++ {
+		result[i] = a[i].ToString()
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestKeyGenBatch(t *testing.T) {
+	l := 8
+	pubKey, mk := setup(l)
+	ids := []string{randomID(l), randomID(l), randomID(l), "0101", "01x10101"}
+
+	results := make(chan *keyResult, len(ids))
+	if err := keyGenBatch(context.Background(), ids, mk, pubKey, chanSink(results)); err != nil {
+		t.Fatal(err)
+	}
+	close(results)
+
+	keys, failed := 0, 0
+	for res := range results {
+		if res.err != nil {
+			failed++
+			continue
+		}
+		keys++
+		if err := verifyKey(pubKey, res.id, res.secKey); err != nil {
+			t.Errorf("key for %s: %v", res.id, err)
+		}
+	}
+	if keys != 3 || failed != 2 {
+		t.Errorf("got %d keys and %d errors, want 3 and 2", keys, failed)
+	}
+}
+
+func TestKeyGenBatchFileSink(t *testing.T) {
+	l := 8
+	pubKey, mk := setup(l)
+	ids := []string{randomID(l), "0"}
+
+	var buf bytes.Buffer
+	if err := keyGenBatch(context.Background(), ids, mk, pubKey, newFileSink(&buf)); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := json.NewDecoder(&buf)
+	lines := map[string]*keyResultJSON{}
+	for dec.More() {
+		line := &keyResultJSON{}
+		if err := dec.Decode(line); err != nil {
+			t.Fatal(err)
+		}
+		lines[line.ID] = line
+	}
+	if line := lines[ids[0]]; line == nil || line.SK == nil || len(line.SK.Xelements) != l {
+		t.Errorf("no secret key written for %s", ids[0])
+	}
+	if line := lines[ids[1]]; line == nil || line.Error == "" || line.SK != nil {
+		t.Errorf("no error written for %s", ids[1])
+	}
+}
+
+func TestKeyGenBatchSinkError(t *testing.T) {
+	l := 8
+	pubKey, mk := setup(l)
+	ids := make([]string, 16)
+	for i := range ids {
+		ids[i] = randomID(l)
+	}
+
+	errFull := errors.New("sink full")
+	n := 0
+	err := keyGenBatch(context.Background(), ids, mk, pubKey, keySinkFunc(func(res *keyResult) error {
+		n++
+		if n == 2 {
+			return errFull
+		}
+		return nil
+	}))
+	if err != errFull {
+		t.Errorf("got %v, want the error of the sink", err)
+	}
+	if n != 2 {
+		t.Errorf("sink called %d times after it failed", n-2)
+	}
+}
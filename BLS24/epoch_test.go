@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEpochRotate(t *testing.T) {
+	m := newEpochManager(8)
+	first := m.current()
+	next := m.rotate()
+	if next.number != first.number+1 || m.current() != next {
+		t.Fatalf("rotate gave epoch %d, current is %d", next.number, m.current().number)
+	}
+	if m.get(first.number) != first {
+		t.Error("the old epoch is no longer active after rotate")
+	}
+	if next.pubKey.omega.Equals(first.pubKey.omega) {
+		t.Error("rotate did not set up a new system")
+	}
+
+	// one M under every active epoch
+	id, s := genSubset(8, 0.5)
+	ciphers, message := m.encrypt(s)
+	if len(ciphers) != 2 {
+		t.Fatalf("%d headers for 2 active epochs", len(ciphers))
+	}
+	for i, e := range []*epoch{first, next} {
+		mk := e.mk.get()
+		secKey := keyGen(id, mk, e.pubKey)
+		wipeECP(mk)
+		if ciphers[i].epoch != e.number {
+			t.Errorf("header %d is for epoch %d, want %d", i, ciphers[i].epoch, e.number)
+		}
+		mes, err := decrypt(s, id, secKey, ciphers[i].cipher)
+		if err != nil || !mes.Equals(message) {
+			t.Errorf("epoch %d: header does not decrypt to the returned M: %v", e.number, err)
+		}
+	}
+}
+
+func TestEpochRekey(t *testing.T) {
+	ids := []string{"01101010", "11101110"}
+	m := newEpochManager(len(ids[0]))
+	old := m.current()
+	mk := old.mk.get()
+	keys := []*sk{keyGen(ids[0], mk, old.pubKey), keyGen(ids[1], mk, old.pubKey)}
+	wipeECP(mk)
+
+	next := m.rotate()
+	envelopes, err := m.rekey(old.number, next.number, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, s := ids[0], &subset{cl: "*1****10", rl: "*****110"}
+	newKey, err := openEnvelope(envelopes[0], keys[0], next.pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphers, message := m.encrypt(s)
+	mes, err := decrypt(s, id, newKey, ciphers[1].cipher)
+	if err != nil || !mes.Equals(message) {
+		t.Errorf("key from the envelope does not decrypt the new epoch: %v", err)
+	}
+
+	// the envelope of another device, and one moved to another epoch
+	if _, err := openEnvelope(envelopes[1], keys[0], next.pubKey); err != errWrongKey {
+		t.Errorf("foreign envelope: got %v, want errWrongKey", err)
+	}
+	moved := *envelopes[0]
+	moved.epoch++
+	if _, err := openEnvelope(&moved, keys[0], next.pubKey); err == nil {
+		t.Error("envelope with a changed epoch was opened")
+	}
+
+	if _, err := m.rekey(old.number, next.number+1, ids); err != errNoEpoch {
+		t.Errorf("rekey to an unknown epoch: got %v, want errNoEpoch", err)
+	}
+}
+
+func TestEpochRetireDue(t *testing.T) {
+	m := newEpochManager(8)
+	old := m.current()
+	next := m.rotate()
+
+	now := time.Now()
+	if err := m.scheduleRetire(next.number, now); err == nil {
+		t.Error("the current epoch was scheduled for retirement")
+	}
+	if err := m.scheduleRetire(old.number, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if retired := m.retireDue(now); len(retired) != 0 {
+		t.Errorf("retired %v before schedule", retired)
+	}
+	if retired := m.retireDue(now.Add(time.Hour)); len(retired) != 1 || retired[0] != old.number {
+		t.Errorf("retired %v, want [%d]", retired, old.number)
+	}
+
+	if m.get(old.number) != nil {
+		t.Error("retired epoch is still active")
+	}
+	if old.mk.buf != nil {
+		t.Error("MK of the retired epoch was not destroyed")
+	}
+	if _, err := m.rekey(old.number, next.number, []string{"01101010"}); err != errNoEpoch {
+		t.Errorf("rekey from a retired epoch: got %v, want errNoEpoch", err)
+	}
+	if ciphers, _ := m.encrypt(&subset{"********", "0*******"}); len(ciphers) != 1 {
+		t.Errorf("%d headers after retirement, want 1", len(ciphers))
+	}
+}
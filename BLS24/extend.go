@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/miracl/core/go/core/BLS24479"
+)
+
+// ----------- ID Length Extension
+// A running system with ID length l is extended to l' > l by appending h_j,b
+// and k_j,b for j = l+1 ... l' to PK. New devices get keys of length l' from
+// keyGen as usual. Existing devices keep their key and are treated as having a
+// wildcard at every new position: they can decrypt headers whose CL and RL are
+// * at all new positions.
+//
+// For that a device needs (h_j,0 * h_j,1)^r for its own r in x0. The new h_j,b
+// are chosen as k0^eta_j,b with eta_j,b known only to the authority, so it can
+// compute the upgrade y0^(sum eta_j,0 + eta_j,1) from the device's y0 = k0^r
+// without learning r.
+//
+// An upgraded device with ID v behaves like the ID v*...*, which constrains how
+// the new IDs are allocated:
+//   - headers old devices can decrypt have * at all new positions of CL and
+//     RL, so revoking v in such a header also revokes every new ID starting
+//     with v, and covering v also covers them.
+//   - so a new ID must not extend an ID issued before the extension. Give the
+//     new devices prefixes that were never issued, e.g. by reserving part of
+//     the old ID space for the extension.
+// keyGen can not check this as it does not know the old IDs, the authority
+// has to keep track of them.
+
+// ----------- Structs
+
+// authority's record of one extension from oldL to newL
+// eta is the sum of the eta_j,b of all new positions and has to be kept secret
+type idExtension struct {
+	oldL int
+	newL int
+	eta  *BLS24479.BIG
+}
+
+// upgrade material for one device and one extension
+type keyUpgrade struct {
+	oldL int
+	newL int
+	u    *BLS24479.ECP
+}
+
+// key of a device created before one or more extensions
+// secKey has the original length, its x0 includes all applied upgrades, l is the current ID length
+type upgradedKey struct {
+	secKey *sk
+	l      int
+}
+
+var errNotWildcard = errors.New("ERROR: CL and RL must be * at positions added after the key was issued")
+
+// Extend PK to ID length newL
+// returns the new PK, the old one stays valid for headers of the old length
+func extendPK(pubKey *pk, newL int) (*pk, *idExtension, error) {
+	oldL := len(pubKey.helements0)
+	if newL <= oldL {
+		return nil, nil, fmt.Errorf("ERROR: new ID length %d is not longer than %d", newL, oldL)
+	}
+	n := newL - oldL
+
+	q := BLS24479.NewBIGints(BLS24479.CURVE_Order)
+	eta := BLS24479.NewBIGint(0)
+
+	// h_j,b = k0^eta_j,b
+	hNew := make([][]*BLS24479.ECP, 2)
+	for b := 0; b < 2; b++ {
+		hNew[b] = make([]*BLS24479.ECP, n)
+		for j := 0; j < n; j++ {
+			x := BLS24479.Randomnum(q, rng)
+			hNew[b][j] = g1mul(pubKey.k0, x)
+			eta = BLS24479.Modadd(eta, x, q)
+			wipeBIG(x)
+		}
+	}
+
+	// k_j,b are independent random elements like in Setup 4
+	kNew, err := genElements(context.Background(), pubKey.g1, 2, n, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	extended := &pk{
+		p:          pubKey.p,
+		g1:         pubKey.g1,
+		g2:         pubKey.g2,
+		h0:         pubKey.h0,
+		k0:         pubKey.k0,
+		helements0: append(append([]*BLS24479.ECP{}, pubKey.helements0...), hNew[0]...),
+		helements1: append(append([]*BLS24479.ECP{}, pubKey.helements1...), hNew[1]...),
+		kelements0: append(append([]*BLS24479.ECP{}, pubKey.kelements0...), kNew[0]...),
+		kelements1: append(append([]*BLS24479.ECP{}, pubKey.kelements1...), kNew[1]...),
+		omega:      pubKey.omega,
+	}
+	return extended, &idExtension{oldL, newL, eta}, nil
+}
+
+// Compute the upgrade for a device key with y0 = k0^r and z = g2^r
+// The pair is checked with e(y0, g2) = e(k0, z), the device does not have to send anything else.
+func (ext *idExtension) upgrade(pubKey *pk, y0 *BLS24479.ECP, z *BLS24479.ECP4) (*keyUpgrade, error) {
+	if err := checkG1("y0", -1, y0); err != nil {
+		return nil, err
+	}
+	if err := checkG2("z", -1, z); err != nil {
+		return nil, err
+	}
+	if !pairsTo(y0, pubKey.g2, pubKey.k0, z, nil) {
+		return nil, &keyComponentError{"y0/z", -1} // only the pair is known
+	}
+	return &keyUpgrade{ext.oldL, ext.newL, g1mul(y0, ext.eta)}, nil
+}
+
+// wipe the secret of the extension once no more devices have to be upgraded
+func (ext *idExtension) Destroy() {
+	wipeBIG(ext.eta)
+}
+
+// Wrap a key for upgrades, secKey itself is not changed
+func newUpgradedKey(secKey *sk) *upgradedKey {
+	x0 := BLS24479.NewECP()
+	x0.Copy(secKey.x0)
+	return &upgradedKey{
+		secKey: &sk{x0, secKey.xelements, secKey.y0, secKey.yEven, secKey.yOdd, secKey.z},
+		l:      len(secKey.xelements),
+	}
+}
+
+// Apply the upgrade of the next extension
+func (key *upgradedKey) apply(up *keyUpgrade) error {
+	if up.oldL != key.l {
+		return fmt.Errorf("ERROR: upgrade from ID length %d does not fit a key of length %d", up.oldL, key.l)
+	}
+	if err := checkG1("upgrade", -1, up.u); err != nil {
+		return err
+	}
+	g1add(key.secKey.x0, up.u)
+	key.l = up.newL
+	return nil
+}
+
+// Decrypt a header of the extended ID length with an upgraded key
+// id has the original length, s the extended one
+func decryptUpgraded(s *subset, id string, key *upgradedKey, cipher *hdr) (*BLS24479.FP24, error) {
+	if len(s.cl) != key.l || len(s.rl) != key.l {
+		return nil, errLength
+	}
+	l := len(key.secKey.xelements)
+	for i := l; i < key.l; i++ {
+		if s.cl[i] != '*' || s.rl[i] != '*' {
+			return nil, errNotWildcard
+		}
+	}
+
+	// H(CL) at the new positions is in x0 already and K(RL) does not change for wildcards
+	return decrypt(&subset{s.cl[:l], s.rl[:l]}, id, key.secKey, cipher)
+}
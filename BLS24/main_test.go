@@ -0,0 +1,12 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// seed rng once for all tests and benchmarks of this folder
+func TestMain(m *testing.M) {
+	initRNG()
+	os.Exit(m.Run())
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"syscall"
+)
+
+// Allocate n bytes outside the Go heap and lock them into RAM
+// so they are never written to swap
+func lockedAlloc(n int) ([]byte, error) {
+	buf, err := syscall.Mmap(-1, 0, n, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Mlock(buf); err != nil {
+		syscall.Munmap(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Wipe and release memory from lockedAlloc
+func lockedFree(buf []byte) error {
+	wipeBytes(buf)
+	if err := syscall.Munlock(buf); err != nil {
+		return err
+	}
+	return syscall.Munmap(buf)
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import (
+	"errors"
+)
+
+// Memory locking is only implemented for Linux
+func lockedAlloc(n int) ([]byte, error) {
+	return nil, errors.New("ERROR: locked memory is only supported on Linux")
+}
+
+// Wipe memory from lockedAlloc
+func lockedFree(buf []byte) error {
+	wipeBytes(buf)
+	return nil
+}
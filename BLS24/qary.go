@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/miracl/core/go/core/BLS24479"
+)
+
+// ----------- q-ary IDs
+// Variant of the scheme where every ID position holds one of k symbols instead
+// of a bit. IDs, CL and RL are strings over the first k characters of
+// qAlphabet, CL and RL may use * as before. Setup creates h_i,c and k_i,c for
+// c = 0 ... k-1, and KeyGen gives
+//
+//	x0     = g1^(alpha-alphaOmega) * h_ID^r
+//	x_i    = product over c != id_i of h_i,c^r
+//	y0     = k0^r
+//	y_i,c  = k_i,c^r * g1^alphaOmega for c != id_i, k_i,id_i^r for c = id_i
+//	z      = g2^r
+//
+// so a key has (k+1)*l + 2 elements in G1 for IDs of length l, and PK 2*k*l + 2.
+// An ID space of 2^L devices needs l = L/log2(k) positions: k = 4 halves the
+// length of IDs and patterns and gives keys of 5/6 the binary size with a PK
+// of the same size, k = 8 gives a third of the length with binary key size and
+// 4/3 of the binary PK. Encryption produces the normal hdr and decryption works as in the
+// binary scheme, with d the number of fixed RL positions different from the ID.
+
+const qAlphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// ----------- Structs
+
+type pkQ struct {
+	k         int
+	p         *BLS24479.BIG
+	g1        *BLS24479.ECP
+	g2        *BLS24479.ECP4
+	h0        *BLS24479.ECP
+	k0        *BLS24479.ECP
+	helements [][]*BLS24479.ECP // h_i,c at [i][c]
+	kelements [][]*BLS24479.ECP // k_i,c at [i][c]
+	omega     *BLS24479.FP24
+}
+
+type skQ struct {
+	x0        *BLS24479.ECP
+	xelements []*BLS24479.ECP
+	y0        *BLS24479.ECP
+	yelements [][]*BLS24479.ECP // y_i,c at [i][c]
+	z         *BLS24479.ECP4
+}
+
+// Setup Algorithm for alphabet size k (l, k) -> PK, MK
+func setupQ(l int, k int) (pubKey *pkQ, mk *BLS24479.ECP, err error) {
+	if k < 2 || k > len(qAlphabet) {
+		return nil, nil, fmt.Errorf("ERROR: alphabet size must be between 2 and %d", len(qAlphabet))
+	}
+
+	q := BLS24479.NewBIGints(BLS24479.CURVE_Order)
+	g1 := BLS24479.ECP_generator()
+	g2 := BLS24479.ECP4_generator()
+
+	alpha := BLS24479.Randomnum(q, rng)
+	defer wipeBIG(alpha)
+
+	h0Rand := BLS24479.Randomnum(q, rng)
+	h0 := g1mul(g1, h0Rand)
+	wipeBIG(h0Rand)
+	k0Rand := BLS24479.Randomnum(q, rng)
+	k0 := g1mul(g1, k0Rand)
+	wipeBIG(k0Rand)
+
+	// h_i,c and k_i,c, k slices of l elements each
+	elements, err := genElements(context.Background(), g1, 2*k, l, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	helements := make([][]*BLS24479.ECP, l)
+	kelements := make([][]*BLS24479.ECP, l)
+	for i := 0; i < l; i++ {
+		helements[i] = make([]*BLS24479.ECP, k)
+		kelements[i] = make([]*BLS24479.ECP, k)
+		for c := 0; c < k; c++ {
+			helements[i][c] = elements[c][i]
+			kelements[i][c] = elements[k+c][i]
+		}
+	}
+
+	mk = g1mul(g1, alpha)
+	omega := gtpow(fexp(ate(g2, g1)), alpha)
+
+	pubKey = &pkQ{k, BLS24479.NewBIGints(BLS24479.Modulus), g1, g2, h0, k0, helements, kelements, omega}
+	return pubKey, mk, nil
+}
+
+// KeyGen Algorithm (ID, MK, PK) -> SK_ID for an ID over the alphabet of PK
+func keyGenQ(id string, mk *BLS24479.ECP, pubKey *pkQ) (*skQ, error) {
+	l := len(pubKey.helements)
+	if err := checkQ(id, l, pubKey.k, false); err != nil {
+		return nil, err
+	}
+
+	q := BLS24479.NewBIGints(BLS24479.CURVE_Order)
+	alphaOmega := BLS24479.Randomnum(q, rng)
+	r := BLS24479.Randomnum(q, rng)
+	defer wipeBIG(alphaOmega)
+	defer wipeBIG(r)
+
+	g1AlphaOmega := g1mul(pubKey.g1, alphaOmega)
+	defer wipeECP(g1AlphaOmega)
+
+	// x0 = mk * g1^-alphaOmega * h_ID^r
+	x0 := g1mul(aggregateHQ(id, pubKey), r)
+	g1add(x0, mk)
+	negAlphaOmega := BLS24479.NewECP()
+	negAlphaOmega.Copy(g1AlphaOmega)
+	negAlphaOmega.Neg()
+	g1add(x0, negAlphaOmega)
+	wipeECP(negAlphaOmega)
+
+	xelements := make([]*BLS24479.ECP, l)
+	yelements := make([][]*BLS24479.ECP, l)
+	for i := 0; i < l; i++ {
+		own := qSymbol(id[i])
+
+		// x_i = (product over c != id_i of h_i,c)^r
+		hOther := BLS24479.NewECP()
+		for c := 0; c < pubKey.k; c++ {
+			if c != own {
+				g1add(hOther, pubKey.helements[i][c])
+			}
+		}
+		xelements[i] = g1mul(hOther, r)
+
+		yelements[i] = make([]*BLS24479.ECP, pubKey.k)
+		for c := 0; c < pubKey.k; c++ {
+			yelements[i][c] = g1mul(pubKey.kelements[i][c], r)
+			if c != own {
+				g1add(yelements[i][c], g1AlphaOmega)
+			}
+		}
+	}
+
+	return &skQ{x0, xelements, g1mul(pubKey.k0, r), yelements, g2mul(pubKey.g2, r)}, nil
+}
+
+// Encrypt(S=(CL,RL), PK, M) -> Hdr_S for patterns over the alphabet of PK
+func encryptQ(s *subset, pubKey *pkQ, message *BLS24479.FP24) (*hdr, error) {
+	l := len(pubKey.helements)
+	if err := checkQ(s.cl, l, pubKey.k, true); err != nil {
+		return nil, err
+	}
+	if err := checkQ(s.rl, l, pubKey.k, true); err != nil {
+		return nil, err
+	}
+
+	// K(RL) = k0 * product of k_i,RLi (nothing for wildcards)
+	krl := BLS24479.NewECP()
+	krl.Copy(pubKey.k0)
+	for i := 0; i < l; i++ {
+		if s.rl[i] != '*' {
+			g1add(krl, pubKey.kelements[i][qSymbol(s.rl[i])])
+		}
+	}
+
+	// encryptWith only uses g2 and omega of the public key
+	base := &pk{p: pubKey.p, g1: pubKey.g1, g2: pubKey.g2, omega: pubKey.omega}
+	return encryptWith(aggregateHQ(s.cl, pubKey), krl, base, message), nil
+}
+
+// Decrypt(S=(CL,RL), ID, SK_ID, Hdr_S) -> M or error
+// As decrypt it validates the header on every call and the secret key not at
+// all: keys from keyGenQ are valid, any other key has to pass secKey.Validate() first.
+func decryptQ(s *subset, id string, secKey *skQ, cipher *hdr) (*BLS24479.FP24, error) {
+	if err := cipher.Validate(); err != nil {
+		return nil, err
+	}
+	l := len(secKey.xelements)
+	if len(id) != l || len(s.cl) != l || len(s.rl) != l || len(secKey.yelements) != l {
+		return nil, errLength
+	}
+
+	// x' = x0 * product of x_i for wildcards in CL
+	xy := BLS24479.NewECP()
+	xy.Copy(secKey.x0)
+	for i := 0; i < l; i++ {
+		if s.cl[i] == '*' {
+			g1add(xy, secKey.xelements[i])
+		}
+	}
+
+	// y' = y0 * product of y_i,RLi, d counts the fixed RL positions different from the ID
+	yAp := BLS24479.NewECP()
+	yAp.Copy(secKey.y0)
+	d := 0
+	for i := 0; i < l; i++ {
+		if s.rl[i] == '*' {
+			continue
+		}
+		c := qSymbol(s.rl[i])
+		if c < 0 || c >= len(secKey.yelements[i]) {
+			return nil, errors.New("RL could not be read")
+		}
+		if s.rl[i] != id[i] {
+			d++
+		}
+		g1add(yAp, secKey.yelements[i][c])
+	}
+	if d == 0 {
+		return nil, errRevoked
+	}
+
+	dExp := BLS24479.NewBIGint(d)
+	dExp.Invmodp(BLS24479.NewBIGints(BLS24479.CURVE_Order))
+	g1add(xy, g1mul(yAp, dExp)) // x' * y'^(d^-1)
+
+	return decryptWith(xy, dExp, secKey.z, cipher), nil
+}
+
+// compute H(CL) = h0 * product of h_i,CLi (all h_i,c for wildcards)
+// also gives h_ID for an ID without wildcards
+func aggregateHQ(cl string, pubKey *pkQ) *BLS24479.ECP {
+	hcl := BLS24479.NewECP()
+	hcl.Copy(pubKey.h0)
+	for i := 0; i < len(cl); i++ {
+		if cl[i] == '*' {
+			for c := 0; c < pubKey.k; c++ {
+				g1add(hcl, pubKey.helements[i][c])
+			}
+		} else {
+			g1add(hcl, pubKey.helements[i][qSymbol(cl[i])])
+		}
+	}
+	return hcl
+}
+
+// check that s has length l and only uses the first k symbols of qAlphabet, and * if wildcards is set
+func checkQ(s string, l int, k int, wildcards bool) error {
+	if len(s) != l {
+		return fmt.Errorf("ERROR: %q has length %d, expected %d", s, len(s), l)
+	}
+	for i := 0; i < l; i++ {
+		if wildcards && s[i] == '*' {
+			continue
+		}
+		if c := qSymbol(s[i]); c < 0 || c >= k {
+			return fmt.Errorf("ERROR: %q is not a symbol of an alphabet of size %d", s[i], k)
+		}
+	}
+	return nil
+}
+
+// value of a symbol of qAlphabet, -1 if it is none
+func qSymbol(b byte) int {
+	for c := 0; c < len(qAlphabet); c++ {
+		if qAlphabet[c] == b {
+			return c
+		}
+	}
+	return -1
+}
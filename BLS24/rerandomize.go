@@ -0,0 +1,98 @@
+package main
+
+import (
+	"github.com/miracl/core/go/core/BLS24479"
+)
+
+// ----------- Re-randomization
+// Keys and headers are re-randomized with PK alone by multiplying in a fresh
+// copy of their random part. The result is distributed like a newly generated
+// one and decrypts exactly the same.
+
+// Rerandomize SK_ID with fresh exponents r' and alphaOmega'
+// The result equals keyGen with r + r' and alphaOmega + alphaOmega', so a copy of the
+// old key no longer matches the stored one bit by bit. secKey itself is not changed.
+func rerandomizeKey(pubKey *pk, id string, secKey *sk) (*sk, error) {
+	l := len(pubKey.helements0)
+	if err := checkID(id, l); err != nil {
+		return nil, err
+	}
+	if len(secKey.xelements) != l || len(secKey.yEven) != l || len(secKey.yOdd) != l {
+		return nil, errLength
+	}
+
+	q := BLS24479.NewBIGints(BLS24479.CURVE_Order)
+	alphaOmega := BLS24479.Randomnum(q, rng)
+	r := BLS24479.Randomnum(q, rng)
+	defer wipeBIG(alphaOmega)
+	defer wipeBIG(r)
+
+	g1AlphaOmega := g1mul(pubKey.g1, alphaOmega)
+	defer wipeECP(g1AlphaOmega)
+
+	// x0 * h_ID^r' * g1^-alphaOmega'
+	x0 := g1mul(computeHID(id, pubKey), r)
+	g1add(x0, secKey.x0)
+	negAlphaOmega := BLS24479.NewECP()
+	negAlphaOmega.Copy(g1AlphaOmega)
+	negAlphaOmega.Neg()
+	g1add(x0, negAlphaOmega)
+	wipeECP(negAlphaOmega)
+
+	// y0 * k0^r'
+	y0 := g1mul(pubKey.k0, r)
+	g1add(y0, secKey.y0)
+
+	xelements := make([]*BLS24479.ECP, l)
+	yEven := make([]*BLS24479.ECP, l)
+	yOdd := make([]*BLS24479.ECP, l)
+	for i := 0; i < l; i++ {
+		hOther, kOwn, kOther := pubKey.helements1[i], pubKey.kelements0[i], pubKey.kelements1[i]
+		if id[i] == '1' {
+			hOther, kOwn, kOther = pubKey.helements0[i], pubKey.kelements1[i], pubKey.kelements0[i]
+		}
+
+		// x_i * h_i,(1-idi)^r'
+		xelements[i] = g1mul(hOther, r)
+		g1add(xelements[i], secKey.xelements[i])
+
+		// y_2i * k_i,idi^r'
+		yEven[i] = g1mul(kOwn, r)
+		g1add(yEven[i], secKey.yEven[i])
+
+		// y_2i-1 * k_i,(1-idi)^r' * g1^alphaOmega'
+		yOdd[i] = g1mul(kOther, r)
+		g1add(yOdd[i], secKey.yOdd[i])
+		g1add(yOdd[i], g1AlphaOmega)
+	}
+
+	// z * g2^r'
+	z := g2mul(pubKey.g2, r)
+	g2add(z, secKey.z)
+
+	return &sk{x0, xelements, y0, yEven, yOdd, z}, nil
+}
+
+// Rerandomize Hdr_S with a fresh exponent t'
+// This multiplies in an encryption of M = 1 under the same subset, giving the header
+// encrypt would have produced with t + t'. Every device decrypts the same message,
+// so a confirmation value stored with the header stays valid. cipher itself is not
+// changed. All of C0 - C3 are refreshed and the header has no other fields, so a
+// retransmission can not be linked to the original by its header.
+func rerandomizeHdr(pubKey *pk, s *subset, cipher *hdr) (*hdr, error) {
+	if err := checkSubset(s, len(pubKey.helements0)); err != nil {
+		return nil, err
+	}
+	if err := cipher.Validate(); err != nil {
+		return nil, err
+	}
+
+	// (omega^t', g2^t', H(CL)^t', K(RL)^t')
+	blank := encryptWith(aggregateH(s.cl, pubKey), aggregateK(s.rl, pubKey), pubKey, BLS24479.NewFP24int(1))
+
+	gtmul(blank.c0, cipher.c0)
+	g2add(blank.c1, cipher.c1)
+	g1add(blank.c2, cipher.c2)
+	g1add(blank.c3, cipher.c3)
+	return blank, nil
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/miracl/core/go/core/BLS24479"
+)
+
+// ----------- Serialization
+// pk, sk and hdr are written as the concatenation of their elements, points and
+// GT elements in compressed form. pk and sk start with l as a 4 byte big endian number.
+// The decoders validate everything they read.
+
+const (
+	fpBytes = int(BLS24479.MODBYTES)
+	g1Bytes = fpBytes + 1        // compressed ECP
+	g2Bytes = 4*fpBytes + 1      // compressed ECP4
+	gtBytes = 3*gtCoeffBytes + 1 // compressed GT element, see compressGT
+)
+
+var errShortInput = errors.New("ERROR: input too short")
+var errLongInput = errors.New("ERROR: unexpected bytes after end of input")
+
+// serialise the public key: l, g1, g2, h0, k0, h_i,0, h_i,1, k_i,0, k_i,1, omega
+func (pubKey *pk) toBytes() []byte {
+	l := len(pubKey.helements0)
+	buf := make([]byte, 0, 4+(3+4*l)*g1Bytes+g2Bytes+gtBytes)
+	buf = appendLen(buf, l)
+	buf = appendG1(buf, pubKey.g1)
+	buf = appendG2(buf, pubKey.g2)
+	buf = appendG1(buf, pubKey.h0)
+	buf = appendG1(buf, pubKey.k0)
+	for _, elements := range [][]*BLS24479.ECP{pubKey.helements0, pubKey.helements1, pubKey.kelements0, pubKey.kelements1} {
+		for i := 0; i < l; i++ {
+			buf = appendG1(buf, elements[i])
+		}
+	}
+	return appendGT(buf, pubKey.omega)
+}
+
+// deserialise and validate a public key
+func pkFromBytes(b []byte) (*pk, error) {
+	r := &byteReader{b: b}
+	l := r.len()
+	pubKey := &pk{p: BLS24479.NewBIGints(BLS24479.Modulus)}
+	pubKey.g1 = r.g1()
+	pubKey.g2 = r.g2()
+	pubKey.h0 = r.g1()
+	pubKey.k0 = r.g1()
+	pubKey.helements0 = r.g1s(l)
+	pubKey.helements1 = r.g1s(l)
+	pubKey.kelements0 = r.g1s(l)
+	pubKey.kelements1 = r.g1s(l)
+	pubKey.omega = r.gt()
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	if err := pubKey.Validate(); err != nil {
+		return nil, err
+	}
+	return pubKey, nil
+}
+
+// serialise the secret key: l, x0, x_i, y0, y_2i, y_2i-1, z
+func (secKey *sk) toBytes() []byte {
+	l := len(secKey.xelements)
+	buf := make([]byte, 0, 4+(2+3*l)*g1Bytes+g2Bytes)
+	buf = appendLen(buf, l)
+	buf = appendG1(buf, secKey.x0)
+	for i := 0; i < l; i++ {
+		buf = appendG1(buf, secKey.xelements[i])
+	}
+	buf = appendG1(buf, secKey.y0)
+	for i := 0; i < l; i++ {
+		buf = appendG1(buf, secKey.yEven[i])
+	}
+	for i := 0; i < l; i++ {
+		buf = appendG1(buf, secKey.yOdd[i])
+	}
+	return appendG2(buf, secKey.z)
+}
+
+// deserialise and validate a secret key
+func skFromBytes(b []byte) (*sk, error) {
+	r := &byteReader{b: b}
+	l := r.len()
+	secKey := &sk{}
+	secKey.x0 = r.g1()
+	secKey.xelements = r.g1s(l)
+	secKey.y0 = r.g1()
+	secKey.yEven = r.g1s(l)
+	secKey.yOdd = r.g1s(l)
+	secKey.z = r.g2()
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	if err := secKey.Validate(); err != nil {
+		return nil, err
+	}
+	return secKey, nil
+}
+
+// serialise the header: C0, C1, C2, C3
+func (cipher *hdr) toBytes() []byte {
+	buf := make([]byte, 0, gtBytes+g2Bytes+2*g1Bytes)
+	buf = appendGT(buf, cipher.c0)
+	buf = appendG2(buf, cipher.c1)
+	buf = appendG1(buf, cipher.c2)
+	return appendG1(buf, cipher.c3)
+}
+
+// deserialise and validate a header
+func hdrFromBytes(b []byte) (*hdr, error) {
+	r := &byteReader{b: b}
+	cipher := &hdr{}
+	cipher.c0 = r.gt()
+	cipher.c1 = r.g2()
+	cipher.c2 = r.g1()
+	cipher.c3 = r.g1()
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	if err := cipher.Validate(); err != nil {
+		return nil, err
+	}
+	return cipher, nil
+}
+
+//-----Helper Functions
+
+func appendLen(buf []byte, l int) []byte {
+	var t [4]byte
+	binary.BigEndian.PutUint32(t[:], uint32(l))
+	return append(buf, t[:]...)
+}
+
+func appendG1(buf []byte, P *BLS24479.ECP) []byte {
+	var t [g1Bytes]byte
+	P.ToBytes(t[:], true)
+	return append(buf, t[:]...)
+}
+
+func appendG2(buf []byte, P *BLS24479.ECP4) []byte {
+	var t [g2Bytes]byte
+	P.ToBytes(t[:], true)
+	return append(buf, t[:]...)
+}
+
+func appendGT(buf []byte, m *BLS24479.FP24) []byte {
+	return append(buf, compressGT(m)...)
+}
+
+// reads elements one after the other, after the first error all reads return nil
+type byteReader struct {
+	b   []byte
+	err error
+}
+
+func (r *byteReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.b) < n {
+		r.err = errShortInput
+		return nil
+	}
+	t := r.b[:n]
+	r.b = r.b[n:]
+	return t
+}
+
+// 4 byte big endian number without further checks
+func (r *byteReader) uint32() int {
+	t := r.next(4)
+	if t == nil {
+		return 0
+	}
+	return int(binary.BigEndian.Uint32(t))
+}
+
+func (r *byteReader) len() int {
+	l := r.uint32()
+	if r.err != nil {
+		return 0
+	}
+	// every position needs at least one G1 element, so larger values cannot be valid
+	if l > len(r.b)/g1Bytes {
+		r.err = errShortInput
+		return 0
+	}
+	return l
+}
+
+func (r *byteReader) g1() *BLS24479.ECP {
+	t := r.next(g1Bytes)
+	if t == nil {
+		return nil
+	}
+	return BLS24479.ECP_fromBytes(t)
+}
+
+func (r *byteReader) g1s(l int) []*BLS24479.ECP {
+	elements := make([]*BLS24479.ECP, l)
+	for i := 0; i < l; i++ {
+		elements[i] = r.g1()
+	}
+	return elements
+}
+
+func (r *byteReader) g2() *BLS24479.ECP4 {
+	t := r.next(g2Bytes)
+	if t == nil {
+		return nil
+	}
+	return BLS24479.ECP4_fromBytes(t)
+}
+
+func (r *byteReader) gt() *BLS24479.FP24 {
+	t := r.next(gtBytes)
+	if t == nil {
+		return nil
+	}
+	m, err := decompressGT(t)
+	if err != nil {
+		r.err = err
+		return nil
+	}
+	return m
+}
+
+// check that the whole input was read
+func (r *byteReader) done() error {
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.b) > 0 {
+		return errLongInput
+	}
+	return nil
+}
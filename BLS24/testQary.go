@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+)
+
+// func main() {
+
+// 	// Initialise Random number generator
+// 	initRNG()
+
+// 	// 16 bit IDs as 8 symbols of a 4 letter alphabet
+// 	testQary("01232103", &subset{cl: "*1**21**", rl: "****3**2"}, 4)
+// }
+
+// Function to run setup, keyGen, encrypt and decrypt with IDs over an alphabet of size k
+func testQary(id string, s *subset, k int) {
+
+	fmt.Println("\n")
+	fmt.Println("-------  q-ary IDs  ---------")
+	fmt.Println("Alphabet size: ", k, " ID: ", id)
+
+	pubKey, mk, err := setupQ(len(id), k)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	secKey, err := keyGenQ(id, mk, pubKey)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Secret key valid: ", secKey.Validate() == nil)
+
+	message := createRandomM(&pk{g1: pubKey.g1, g2: pubKey.g2})
+	cipher, err := encryptQ(s, pubKey, message)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	mes, err := decryptQ(s, id, secKey, cipher)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	printDecrypt(message, mes, nil)
+}
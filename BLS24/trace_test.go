@@ -0,0 +1,79 @@
+package main
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/miracl/core/go/core/BLS24479"
+)
+
+func TestTraceTraitors(t *testing.T) {
+	l := 6
+	pubKey, mk := setup(l)
+	keysOf := func(traitors []string) []*sk {
+		keys := make([]*sk, len(traitors))
+		for j, id := range traitors {
+			keys[j] = keyGen(id, mk, pubKey)
+		}
+		return keys
+	}
+
+	// pirates trying every key
+	cases := map[string][]string{
+		"one key":       {"011010"},
+		"two keys":      {"011010", "011011"},
+		"three keys":    {"000000", "101101", "111111"},
+		"shared prefix": {"111000", "111001", "111010"},
+	}
+	for name, traitors := range cases {
+		checkTraced(t, name, pubKey, allKeysPirate(traitors, keysOf(traitors)), 1, traitors)
+	}
+
+	// a pirate picking one of two keys at random per header misses a prefix in all 20 trials with probability 2^-20
+	traitors := []string{"010101", "110011"}
+	checkTraced(t, "random key", pubKey, newPirate(traitors, keysOf(traitors)), 20, traitors)
+
+	nothing := func(s *subset, cipher *hdr) *BLS24479.FP24 { return nil }
+	checkTraced(t, "no key", pubKey, nothing, 1, nil)
+	if _, _, err := traceTraitors(pubKey, nothing, 0); err == nil {
+		t.Error("zero trials accepted")
+	}
+}
+
+// check that tracing pirate returns exactly the IDs of traitors
+func checkTraced(t *testing.T, name string, pubKey *pk, pirate pirateDecoder, trials int, traitors []string) {
+	t.Helper()
+	results, _, err := traceTraitors(pubKey, pirate, trials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	traced := []string{}
+	for _, res := range results {
+		traced = append(traced, res.id)
+	}
+	want := append([]string{}, traitors...)
+	sort.Strings(traced)
+	sort.Strings(want)
+	if len(traced) != len(want) {
+		t.Errorf("%s: traced %v, want %v", name, traced, want)
+		return
+	}
+	for i := range want {
+		if traced[i] != want[i] {
+			t.Errorf("%s: traced %v, want %v", name, traced, want)
+			return
+		}
+	}
+}
+
+// pirate decoder decrypting with the first of keys that works
+func allKeysPirate(ids []string, keys []*sk) pirateDecoder {
+	return func(s *subset, cipher *hdr) *BLS24479.FP24 {
+		for j := range keys {
+			if mes, err := decrypt(s, ids[j], keys[j], cipher); err == nil {
+				return mes
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/miracl/core/go/core/BLS24479"
+)
+
+// ----------- Structs
+
+// component of a secret key that does not match the public key
+// index is the subscript as in printKeyGen, e.g. x3 or y5, 0 for x0 and y0,
+// or -1 for components without one such as z
+type keyComponentError struct {
+	component string
+	index     int
+}
+
+func (e *keyComponentError) Error() string {
+	name := e.component
+	if e.index >= 0 {
+		name = fmt.Sprintf("%s%d", e.component, e.index)
+	}
+	return fmt.Sprintf("ERROR: secret key component %s is inconsistent with the public key", name)
+}
+
+// Verify that SK_ID was correctly derived from PK for ID, without knowing MK
+// Every component is checked with a pairing equation against z = g2^r:
+//
+//	e(y0, g2)        = e(k0, z)
+//	e(x_i, g2)       = e(h_i,(1-idi), z)
+//	e(y_2i, g2)      = e(k_i,idi, z)
+//	e(x0*y_2i-1, g2) = e(h_ID * k_i,(1-idi), z) * omega
+//
+// The last one holds because g1^(alpha-alphaOmega) in x0 and g1^alphaOmega in y_2i-1 cancel out.
+// Returns nil if the key is consistent, else a *keyComponentError or a validation error.
+func verifyKey(pubKey *pk, id string, secKey *sk) error {
+	l := len(pubKey.helements0)
+	if err := checkID(id, l); err != nil {
+		return err
+	}
+	if err := secKey.Validate(); err != nil {
+		return err
+	}
+	if len(secKey.xelements) != l {
+		return errLength
+	}
+
+	// equations of x_i and y_2i
+	position := func(i int) (xOK bool, yOK bool) {
+		hOther, kOwn := pubKey.helements1[i], pubKey.kelements0[i]
+		if id[i] == '1' {
+			hOther, kOwn = pubKey.helements0[i], pubKey.kelements1[i]
+		}
+		xOK = pairsTo(secKey.xelements[i], pubKey.g2, hOther, secKey.z, nil)
+		yOK = pairsTo(secKey.yEven[i], pubKey.g2, kOwn, secKey.z, nil)
+		return xOK, yOK
+	}
+
+	// -- y0 and z
+	// a wrong z breaks every equation, so if x1 or y2 still holds z is right
+	// and y0 is wrong. Without positions the two can not be told apart.
+	if !pairsTo(secKey.y0, pubKey.g2, pubKey.k0, secKey.z, nil) {
+		if l == 0 {
+			return &keyComponentError{"y0/z", -1}
+		}
+		if xOK, yOK := position(0); xOK || yOK {
+			return &keyComponentError{"y", 0}
+		}
+		return &keyComponentError{"z", -1}
+	}
+
+	// -- x1 - xl and y2, y4, ... y2l
+	for i := 0; i < l; i++ {
+		xOK, yOK := position(i)
+		if !xOK {
+			return &keyComponentError{"x", i + 1}
+		}
+		if !yOK {
+			return &keyComponentError{"y", 2 * (i + 1)}
+		}
+	}
+
+	// -- x0 together with y1, y3, ... y2l-1
+	hID := computeHID(id, pubKey)
+	failed := []int{}
+	for i := 0; i < l; i++ {
+		kOther := pubKey.kelements1[i]
+		if id[i] == '1' {
+			kOther = pubKey.kelements0[i]
+		}
+		lhs := BLS24479.NewECP()
+		lhs.Copy(secKey.x0)
+		g1add(lhs, secKey.yOdd[i])
+		rhs := BLS24479.NewECP()
+		rhs.Copy(hID)
+		g1add(rhs, kOther)
+		if !pairsTo(lhs, pubKey.g2, rhs, secKey.z, pubKey.omega) {
+			failed = append(failed, i)
+		}
+	}
+
+	// if every equation fails x0 is the common culprit, unless there is only one
+	if len(failed) == 0 {
+		return nil
+	}
+	if len(failed) == l && l > 1 {
+		return &keyComponentError{"x", 0}
+	}
+	return &keyComponentError{"y", 2*(failed[0]+1) - 1}
+}
+
+// check e(A, P) = e(B, Q) * m, or e(A, P) = e(B, Q) if m is nil
+// evaluated as e(A, P) * e(B^-1, Q) with a single final exponentiation
+func pairsTo(A *BLS24479.ECP, P *BLS24479.ECP4, B *BLS24479.ECP, Q *BLS24479.ECP4, m *BLS24479.FP24) bool {
+	negB := BLS24479.NewECP()
+	negB.Copy(B)
+	negB.Neg()
+
+	e := ate2(P, A, Q, negB)
+	e = fexp(e)
+	if m == nil {
+		return e.Isunity()
+	}
+	return e.Equals(m)
+}
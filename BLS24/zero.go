@@ -0,0 +1,104 @@
+package main
+
+import (
+	"github.com/miracl/core/go/core/BLS24479"
+)
+
+// ----------- Zeroization
+// Secret values are overwritten as soon as they are no longer needed, so they
+// do not stay around in heap memory until the garbage collector reuses it.
+
+// Master key kept in locked memory, see lockedAlloc
+// mk is stored in its serialised form and only decoded while it is used
+type lockedMK struct {
+	buf    []byte
+	locked bool // false if buf is ordinary heap memory
+}
+
+// Move mk into locked memory, mk itself is wiped
+// If memory can not be locked (not Linux, RLIMIT_MEMLOCK) the key is kept on
+// the heap instead and returned together with the error, so callers can decide
+// whether to carry on without locked memory.
+func lockMK(mk *BLS24479.ECP) (*lockedMK, error) {
+	k := &lockedMK{locked: true}
+	buf, err := lockedAlloc(g1Bytes)
+	if err != nil {
+		buf = make([]byte, g1Bytes)
+		k.locked = false
+	}
+	mk.ToBytes(buf, true)
+	wipeECP(mk)
+	k.buf = buf
+	return k, err
+}
+
+// get a copy of the master key, which the caller should wipe after use
+func (k *lockedMK) get() *BLS24479.ECP {
+	return BLS24479.ECP_fromBytes(k.buf)
+}
+
+// overwrite the master key with 0, it can not be used afterwards
+func (k *lockedMK) wipe() {
+	wipeBytes(k.buf)
+}
+
+// wipe the master key and release its memory
+func (k *lockedMK) Destroy() error {
+	if k.buf == nil {
+		return nil
+	}
+	k.wipe()
+	var err error
+	if k.locked {
+		err = lockedFree(k.buf)
+	}
+	k.buf = nil
+	return err
+}
+
+// wipe all components of the secret key
+func (secKey *sk) Destroy() {
+	wipeECP(secKey.x0)
+	wipeECP(secKey.y0)
+	for i := range secKey.xelements {
+		wipeECP(secKey.xelements[i])
+	}
+	for i := range secKey.yEven {
+		wipeECP(secKey.yEven[i])
+	}
+	for i := range secKey.yOdd {
+		wipeECP(secKey.yOdd[i])
+	}
+	wipeECP4(secKey.z)
+}
+
+// overwrite b with 0
+// BIG keeps its limbs in a fixed size array, so assigning the zero value clears
+// every limb in place. b.Mod(1) would also give 0, but leaves the intermediate
+// remainders of the reduction behind in fresh BIGs.
+func wipeBIG(b *BLS24479.BIG) {
+	if b != nil {
+		*b = BLS24479.BIG{}
+	}
+}
+
+// overwrite P with the point at infinity
+func wipeECP(P *BLS24479.ECP) {
+	if P != nil {
+		P.Copy(BLS24479.NewECP())
+	}
+}
+
+// overwrite P with the point at infinity
+func wipeECP4(P *BLS24479.ECP4) {
+	if P != nil {
+		P.Copy(BLS24479.NewECP4())
+	}
+}
+
+// overwrite buf with 0
+func wipeBytes(buf []byte) {
+	for i := range buf {
+		buf[i] = 0
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/miracl/core/go/core/BLS24479"
+)
+
+func TestWipe(t *testing.T) {
+	q := BLS24479.NewBIGints(BLS24479.CURVE_Order)
+	x := BLS24479.Randomnum(q, rng)
+	wipeBIG(x)
+	if !x.IsZilch() {
+		t.Error("wipeBIG left a non-zero BIG")
+	}
+
+	P := BLS24479.G1mul(BLS24479.ECP_generator(), BLS24479.Randomnum(q, rng))
+	wipeECP(P)
+	if !P.Is_infinity() {
+		t.Error("wipeECP left a point other than infinity")
+	}
+
+	Q := BLS24479.G2mul(BLS24479.ECP4_generator(), BLS24479.Randomnum(q, rng))
+	wipeECP4(Q)
+	if !Q.Is_infinity() {
+		t.Error("wipeECP4 left a point other than infinity")
+	}
+}
+
+func TestLockedMK(t *testing.T) {
+	_, mk := setup(4)
+	want := BLS24479.NewECP()
+	want.Copy(mk)
+
+	lk, err := lockMK(mk)
+	if err != nil {
+		t.Log("no locked memory, testing the heap fallback:", err)
+	}
+	if !mk.Is_infinity() {
+		t.Error("lockMK did not wipe mk")
+	}
+	if !lk.get().Equals(want) {
+		t.Error("lockMK did not keep the master key")
+	}
+
+	buf := lk.buf
+	lk.wipe()
+	if !isZero(buf) {
+		t.Error("wipe left master key bytes behind")
+	}
+	if err := lk.Destroy(); err != nil {
+		t.Error(err)
+	}
+	if lk.buf != nil {
+		t.Error("Destroy kept the buffer")
+	}
+}
+
+// lockedFree unmaps locked buffers, so their content can only be checked on a heap key
+func TestLockedMKDestroy(t *testing.T) {
+	buf := make([]byte, g1Bytes)
+	BLS24479.ECP_generator().ToBytes(buf, true)
+
+	lk := &lockedMK{buf: buf}
+	if err := lk.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+	if !isZero(buf) {
+		t.Error("Destroy left master key bytes behind")
+	}
+}
+
+// check that all bytes of buf are 0
+func isZero(buf []byte) bool {
+	for _, b := range buf {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
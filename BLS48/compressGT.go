@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/miracl/core/go/core/BLS48581"
+)
+
+// ----------- GT Compression
+// Elements of GT are unitary: m * conj(m) = 1. Writing FP48 as FP24[sigma] with
+// sigma^2 in FP24, m = A + B*sigma with A, B in FP24, and m != 1 is given by the
+// single FP24 element c = (1 + A) / B of the torus T2:
+//
+//	m = (c + sigma) / (c - sigma)
+//
+// so half of the FP48 coefficients are enough. Compressed elements take one
+// flag byte, 1 for m = 1 and 0 otherwise, followed by c.
+//
+// MIRACL has no accessors for the coefficients, so they are taken from
+// FP48.ToBytes, which writes c, b, a of FP48 = FP16^3 and b, a of every FP16 = FP8^2.
+// With w^3 = s for the generators w of FP48 over FP16 and s of FP16 over FP8,
+// sigma = w^3 = s and the FP24 part of m are the coefficients of 1, w^2 and w^4.
+
+const gtCoeffBytes = 8 * fpBytes // FP8
+
+// positions of the coefficients in gtCoeffs
+const (
+	cw5 = iota // c1: w^5
+	cw2        // c0: w^2
+	cw4        // b1: w^4
+	cw1        // b0: w
+	cw3        // a1: w^3 = sigma
+	cw0        // a0: 1
+)
+
+var errBadGT = errors.New("ERROR: invalid compressed GT element")
+
+// compress a GT element to gtBytes
+func compressGT(m *BLS48581.FP48) []byte {
+	buf := make([]byte, gtBytes)
+	if m.Isunity() {
+		buf[0] = 1
+		return buf
+	}
+
+	zero := BLS48581.NewFP8int(0)
+	k := gtCoeffs(m)
+
+	// 1 + A and B*sigma
+	one := BLS48581.NewFP8copy(k[cw0])
+	one.Add(BLS48581.NewFP8int(1))
+	even := gtFromCoeffs([6]*BLS48581.FP8{zero, k[cw2], k[cw4], zero, zero, one})
+	odd := gtFromCoeffs([6]*BLS48581.FP8{k[cw5], zero, zero, k[cw1], k[cw3], zero})
+
+	// c = (1 + A) * sigma / (B * sigma)
+	odd.Inverse()
+	even.Mul(odd)
+	even.Mul(gtFromCoeffs([6]*BLS48581.FP8{zero, zero, zero, zero, BLS48581.NewFP8int(1), zero}))
+
+	c := gtCoeffs(even)
+	for j, pos := range []int{cw0, cw2, cw4} {
+		c[pos].ToBytes(buf[1+j*gtCoeffBytes : 1+(j+1)*gtCoeffBytes])
+	}
+	return buf
+}
+
+// decompress a GT element, the result still has to be checked for membership in GT
+func decompressGT(b []byte) (*BLS48581.FP48, error) {
+	if len(b) != gtBytes || b[0] > 1 {
+		return nil, errBadGT
+	}
+	if b[0] == 1 {
+		for _, x := range b[1:] {
+			if x != 0 {
+				return nil, errBadGT
+			}
+		}
+		return BLS48581.NewFP48int(1), nil
+	}
+
+	var c [3]*BLS48581.FP8
+	for j := range c {
+		c[j] = BLS48581.FP8_fromBytes(b[1+j*gtCoeffBytes : 1+(j+1)*gtCoeffBytes])
+	}
+
+	// m = (c + sigma) / (c - sigma)
+	zero := BLS48581.NewFP8int(0)
+	minusOne := BLS48581.NewFP8int(1)
+	minusOne.Neg()
+	num := gtFromCoeffs([6]*BLS48581.FP8{zero, c[1], c[2], zero, BLS48581.NewFP8int(1), c[0]})
+	den := gtFromCoeffs([6]*BLS48581.FP8{zero, c[1], c[2], zero, minusOne, c[0]})
+	den.Inverse()
+	num.Mul(den)
+	return num, nil
+}
+
+// the six FP8 coefficients of m in the order of FP48.ToBytes
+func gtCoeffs(m *BLS48581.FP48) [6]*BLS48581.FP8 {
+	var buf [6 * gtCoeffBytes]byte
+	m.ToBytes(buf[:])
+	var k [6]*BLS48581.FP8
+	for j := range k {
+		k[j] = BLS48581.FP8_fromBytes(buf[j*gtCoeffBytes : (j+1)*gtCoeffBytes])
+	}
+	return k
+}
+
+// inverse of gtCoeffs
+func gtFromCoeffs(k [6]*BLS48581.FP8) *BLS48581.FP48 {
+	return BLS48581.NewFP48fp4s(
+		BLS48581.NewFP16fp2s(k[cw0], k[cw3]),
+		BLS48581.NewFP16fp2s(k[cw1], k[cw4]),
+		BLS48581.NewFP16fp2s(k[cw2], k[cw5]))
+}
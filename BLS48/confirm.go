@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+
+	"github.com/miracl/core/go/core/BLS48581"
+)
+
+// ----------- Key Confirmation
+// A device outside of CL decrypts a header to a random looking element of GT
+// without noticing. The check therefore sits in the key derivation: sealKeys
+// derives the AES-256-GCM data key and a confirmation value from M under
+// separate domain prefixes, the caller stores the confirmation value next to
+// the header, and openKeys fails with errWrongKey if the recovered M does not
+// reproduce it. The header itself is only C0 - C3, all of which rerandomizeHdr
+// refreshes, so a re-randomized header shares no bytes with the original.
+//
+// Anyone can hash a guess of M and compare it with the confirmation value, so
+// M has to be uniformly random and secret: BESTIE is used as a KEM,
+// encapsulate draws a fresh M and the data is encrypted under the derived key,
+// never as M itself. Knowing the confirmation value says nothing about the
+// data key, as both hash M under different prefixes.
+
+const checkBytes = sha256.Size
+
+// domain prefixes of the key derivation
+const keyDST = "BESTIE-KEY-V01"
+const checkDST = "BESTIE-CONFIRM-V01"
+
+var errWrongKey = errors.New("ERROR: key confirmation failed, your ID is not part of the covered group")
+
+// data key for message and the confirmation value to store with its header
+func sealKeys(message *BLS48581.FP48) (cipher.AEAD, []byte, error) {
+	aead, err := dataAEAD(message)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, kdf(checkDST, message), nil
+}
+
+// check the decrypted message against the stored confirmation value and derive the data key
+func openKeys(message *BLS48581.FP48, check []byte) (cipher.AEAD, error) {
+	if subtle.ConstantTimeCompare(kdf(checkDST, message), check) != 1 {
+		return nil, errWrongKey
+	}
+	return dataAEAD(message)
+}
+
+// AES-256-GCM under SHA-256 of M with the key prefix
+func dataAEAD(message *BLS48581.FP48) (cipher.AEAD, error) {
+	key := kdf(keyDST, message)
+	defer wipeBytes(key)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SHA-256 over the domain prefix and M
+func kdf(dst string, message *BLS48581.FP48) []byte {
+	buf := appendGT([]byte(dst), message)
+	h := sha256.Sum256(buf)
+	wipeBytes(buf)
+	return h[:]
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestKeyConfirmation(t *testing.T) {
+	l := 8
+	pubKey, mk := setup(l)
+	id := randomID(l)
+	other := flipBit(id, 0)                                             // outside of CL, not revoked
+	s := &subset{id, strings.Repeat("*", l-1) + flipBit(id, l-1)[l-1:]} // covers only id
+	secKey := keyGen(id, mk, pubKey)
+
+	cipher, message := encapsulate(s, pubKey)
+	aead, check, err := sealKeys(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	sealed := aead.Seal(nil, nonce, []byte("payload"), nil)
+
+	mes, err := decrypt(s, id, secKey, cipher)
+	if err != nil || !mes.Equals(message) {
+		t.Fatalf("encapsulated header does not decrypt: %v", err)
+	}
+	opened, err := openKeys(mes, check)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := opened.Open(nil, nonce, sealed, nil); err != nil || string(plain) != "payload" {
+		t.Errorf("derived key does not open the payload: %v", err)
+	}
+
+	mes, err = decrypt(s, other, keyGen(other, mk, pubKey), cipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openKeys(mes, check); err != errWrongKey {
+		t.Errorf("got %v for an ID outside of CL, want errWrongKey", err)
+	}
+	if _, err := openKeys(message, check[:checkBytes-1]); err != errWrongKey {
+		t.Errorf("got %v for a truncated confirmation value, want errWrongKey", err)
+	}
+
+	// the confirmation value is not the data key
+	if key := kdf(keyDST, message); bytes.Equal(key, check) {
+		t.Error("confirmation value equals the data key")
+	}
+}
+
+func TestRerandomizeHdrUnlinkable(t *testing.T) {
+	l := 8
+	pubKey, mk := setup(l)
+	id, s := genSubset(l, 0.5)
+	secKey := keyGen(id, mk, pubKey)
+	cipher, message := encapsulate(s, pubKey)
+	_, check, err := sealKeys(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newCipher, err := rerandomizeHdr(pubKey, s, cipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newCipher.c0.Equals(cipher.c0) || newCipher.c1.Equals(cipher.c1) ||
+		newCipher.c2.Equals(cipher.c2) || newCipher.c3.Equals(cipher.c3) {
+		t.Error("a component of the header was not re-randomized")
+	}
+	// C0 - C3 are all there is to a header
+	if n := len(newCipher.toBytes()); n != gtBytes+g2Bytes+2*g1Bytes {
+		t.Errorf("header has %d bytes, want only C0 - C3", n)
+	}
+
+	mes, err := decrypt(s, id, secKey, newCipher)
+	if err != nil || !mes.Equals(message) {
+		t.Fatalf("re-randomized header does not decrypt: %v", err)
+	}
+	if _, err := openKeys(mes, check); err != nil {
+		t.Errorf("confirmation value of the original header: %v", err)
+	}
+}
+
+// id with bit i flipped
+func flipBit(id string, i int) string {
+	b := []byte(id)
+	b[i] = '0' + '1' - b[i]
+	return string(b)
+}
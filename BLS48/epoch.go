@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/miracl/core/go/core/BLS48581"
+)
+
+// ----------- Epochs
+// A new system (PK, MK) can be set up at any time as a new epoch. Devices that
+// are not revoked get their new SK_ID in a re-keying envelope encrypted under
+// the old epoch to their ID alone, so no manual re-provisioning is needed.
+// During migration content is encrypted under every active epoch, and old
+// epochs are retired on a schedule, which wipes their MK.
+//
+// Every MK is kept in locked memory (see lockMK) and only decoded while keys
+// are issued. Where memory can not be locked it stays on the heap, which
+// epoch.mk.locked tells.
+//
+// Re-keying under the old epoch only keeps new keys secret from those who do
+// not hold the old MK. If the old MK leaked, envelopes for the new epoch have
+// to be delivered over a different channel.
+
+// ----------- Structs
+
+// one generation of the system
+type epoch struct {
+	number int
+	pubKey *pk
+	mk     *lockedMK
+	retire time.Time // zero while no retirement is scheduled
+}
+
+// new secret key for one device, sealed under the previous epoch
+// cipher encrypts a random M to s = (CL = ID, RL = ID with the first bit flipped)
+// and sealed is the AES-GCM encryption of the new SK under the data key of M
+type rekeyEnvelope struct {
+	id     string
+	epoch  int
+	s      *subset
+	cipher *hdr
+	check  []byte // key confirmation, see confirm.go
+	nonce  []byte
+	sealed []byte
+}
+
+// header of a message for one of the active epochs
+type epochHdr struct {
+	epoch  int
+	cipher *hdr
+}
+
+// all epochs that have not been retired yet, oldest first
+type epochManager struct {
+	mu     sync.RWMutex
+	epochs []*epoch
+}
+
+var errNoEpoch = errors.New("ERROR: no such epoch, it might have been retired")
+
+// Create an epoch manager with a fresh first epoch for ID length l
+func newEpochManager(l int) *epochManager {
+	return &epochManager{epochs: []*epoch{newEpoch(1, l)}}
+}
+
+// set up the system of epoch number and move its MK into locked memory
+func newEpoch(number int, l int) *epoch {
+	pubKey, mk := setup(l)
+	// if memory can not be locked lockMK keeps the key on the heap, see epoch.mk.locked
+	lk, _ := lockMK(mk)
+	return &epoch{number: number, pubKey: pubKey, mk: lk}
+}
+
+// the newest epoch
+func (m *epochManager) current() *epoch {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.epochs[len(m.epochs)-1]
+}
+
+// the epoch with the given number, or nil if it is unknown or retired
+func (m *epochManager) get(number int) *epoch {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.find(number)
+}
+
+// like get, with m.mu already held
+func (m *epochManager) find(number int) *epoch {
+	for _, e := range m.epochs {
+		if e.number == number {
+			return e
+		}
+	}
+	return nil
+}
+
+// Set up a new system as the next epoch, the old epochs stay active
+func (m *epochManager) rotate() *epoch {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cur := m.epochs[len(m.epochs)-1]
+	next := newEpoch(cur.number+1, len(cur.pubKey.helements0))
+	m.epochs = append(m.epochs, next)
+	return next
+}
+
+// Schedule the retirement of an epoch, the current epoch can not be retired
+func (m *epochManager) scheduleRetire(number int, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, e := range m.epochs {
+		if e.number == number {
+			if i == len(m.epochs)-1 {
+				return errors.New("ERROR: the current epoch can not be retired")
+			}
+			e.retire = at
+			return nil
+		}
+	}
+	return errNoEpoch
+}
+
+// Retire all epochs whose retirement time has come and wipe their master keys
+// returns the numbers of the retired epochs
+func (m *epochManager) retireDue(now time.Time) []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	retired := []int{}
+	active := []*epoch{}
+	for _, e := range m.epochs {
+		if !e.retire.IsZero() && !now.Before(e.retire) {
+			// the key is wiped even if its memory can not be released
+			e.mk.Destroy()
+			retired = append(retired, e.number)
+			continue
+		}
+		active = append(active, e)
+	}
+	m.epochs = active
+	return retired
+}
+
+// Encapsulate one fresh M to s under every active epoch
+// devices use the header of the newest epoch they have a key for, and the data
+// is sealed under the keys derived from M (see sealKeys)
+func (m *epochManager) encrypt(s *subset) ([]*epochHdr, *BLS48581.FP48) {
+	m.mu.RLock()
+	epochs := append([]*epoch{}, m.epochs...)
+	m.mu.RUnlock()
+
+	ciphers := make([]*epochHdr, len(epochs))
+	cipher, message := encapsulate(s, epochs[0].pubKey)
+	ciphers[0] = &epochHdr{epochs[0].number, cipher}
+	for i, e := range epochs[1:] {
+		ciphers[i+1] = &epochHdr{e.number, encrypt(s, e.pubKey, message)}
+	}
+	return ciphers, message
+}
+
+// Issue keys of epoch next for the given IDs, each sealed under epoch prev
+// ids must only contain devices that are not revoked, these are not checked here
+// m stays read locked, so epoch next can not be retired while its MK is in use
+func (m *epochManager) rekey(prev int, next int, ids []string) ([]*rekeyEnvelope, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	from := m.find(prev)
+	to := m.find(next)
+	if from == nil || to == nil {
+		return nil, errNoEpoch
+	}
+
+	l := len(to.pubKey.helements0)
+	for _, id := range ids {
+		if err := checkID(id, l); err != nil {
+			return nil, err
+		}
+	}
+
+	mk := to.mk.get()
+	defer wipeECP(mk)
+	envelopes := make([]*rekeyEnvelope, len(ids))
+	for j, id := range ids {
+		secKey := keyGen(id, mk, to.pubKey)
+		env, err := sealKey(from, to.number, id, secKey)
+		secKey.Destroy()
+		if err != nil {
+			return nil, err
+		}
+		envelopes[j] = env
+	}
+	return envelopes, nil
+}
+
+// seal secKey for id under epoch from
+func sealKey(from *epoch, next int, id string, secKey *sk) (*rekeyEnvelope, error) {
+	// only id itself is in CL and not in RL, so d = 1 for id and no other ID is covered
+	rl := []byte(id)
+	rl[0] = '0' + '1' - rl[0]
+	s := &subset{cl: id, rl: string(rl)}
+
+	cipher, message := encapsulate(s, from.pubKey)
+
+	aead, check, err := sealKeys(message)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	for i := range nonce {
+		nonce[i] = rng.GetByte()
+	}
+	plain := secKey.toBytes()
+	sealed := aead.Seal(nil, nonce, plain, envelopeData(id, next))
+	wipeBytes(plain)
+
+	return &rekeyEnvelope{id, next, s, cipher, check, nonce, sealed}, nil
+}
+
+// Open a re-keying envelope with the key of the previous epoch
+// the new key is checked against the public key of its epoch before it is returned
+func openEnvelope(env *rekeyEnvelope, secKey *sk, nextPK *pk) (*sk, error) {
+	message, err := decrypt(env.s, env.id, secKey, env.cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := openKeys(message, env.check)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, env.nonce, env.sealed, envelopeData(env.id, env.epoch))
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: re-keying envelope for epoch %d could not be opened: %v", env.epoch, err)
+	}
+	defer wipeBytes(plain)
+
+	newKey, err := skFromBytes(plain)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyKey(nextPK, env.id, newKey); err != nil {
+		newKey.Destroy()
+		return nil, err
+	}
+	return newKey, nil
+}
+
+// additional data binding an envelope to its ID and epoch
+func envelopeData(id string, number int) []byte {
+	var t [4]byte
+	binary.BigEndian.PutUint32(t[:], uint32(number))
+	return append(t[:], id...)
+}
+
+// random element of GT, omega^x for a random exponent x
+func randomGT(pubKey *pk) *BLS48581.FP48 {
+	q := BLS48581.NewBIGints(BLS48581.CURVE_Order)
+	x := BLS48581.Randomnum(q, rng)
+	defer wipeBIG(x)
+	return gtpow(pubKey.omega, x)
+}
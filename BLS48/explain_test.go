@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExplainVerdicts(t *testing.T) {
+	id := "01101010"
+	pubKey, mk := setup(len(id))
+	secKey := keyGen(id, mk, pubKey)
+
+	for _, test := range []struct {
+		s       *subset
+		verdict verdict
+	}{
+		{&subset{cl: "*1****10", rl: "*****110"}, verdictCovered},
+		{&subset{cl: "*1****10", rl: "*****010"}, verdictRevoked},
+		{&subset{cl: "********", rl: "********"}, verdictRevoked},
+		{&subset{cl: "*0****10", rl: "*****110"}, verdictNotCovered},
+		{&subset{cl: "*1****1", rl: "*****110"}, verdictLengthMismatch},
+		{&subset{cl: "*1****1x", rl: "*****110"}, verdictInvalid},
+	} {
+		e := explain(id, test.s)
+		if e.verdict != test.verdict {
+			t.Errorf("CL %s, RL %s: got %s, want %s", test.s.cl, test.s.rl, e.verdict, test.verdict)
+		}
+		if !strings.Contains(e.String(), "Verdict: "+test.verdict.String()) {
+			t.Errorf("CL %s, RL %s: report does not give the verdict:\n%s", test.s.cl, test.s.rl, e)
+		}
+		if (e.err != nil) != (test.verdict == verdictLengthMismatch || test.verdict == verdictInvalid) {
+			t.Errorf("CL %s, RL %s: reason %v for verdict %s", test.s.cl, test.s.rl, e.err, e.verdict)
+		}
+
+		// the verdict has to agree with decrypt
+		if e.err != nil {
+			continue
+		}
+		cipher, message := encapsulate(test.s, pubKey)
+		mes, err := decrypt(test.s, id, secKey, cipher)
+		switch test.verdict {
+		case verdictCovered:
+			if err != nil || !mes.Equals(message) {
+				t.Errorf("covered, but decrypt failed: %v", err)
+			}
+		case verdictRevoked:
+			if err != errRevoked {
+				t.Errorf("revoked, but decrypt gave %v", err)
+			}
+		case verdictNotCovered:
+			if err == nil && mes.Equals(message) {
+				t.Error("not covered, but decrypt succeeded")
+			}
+		}
+	}
+
+	if invalid := explain("0110101x", &subset{cl: "*1****10", rl: "*****110"}); invalid.verdict != verdictInvalid {
+		t.Errorf("invalid ID: got %s, want %s", invalid.verdict, verdictInvalid)
+	}
+}
+
+func TestExplainPositions(t *testing.T) {
+	e := explain("01101010", &subset{cl: "*0****11", rl: "1****110"})
+	if !reflect.DeepEqual(e.clMismatch, []int{2, 8}) {
+		t.Errorf("CL mismatch at %v, want [2 8]", e.clMismatch)
+	}
+	if !reflect.DeepEqual(e.p, []int{1, 6}) || !reflect.DeepEqual(e.q, []int{7, 8}) || e.d != 2 {
+		t.Errorf("P = %v, Q = %v, d = %d, want P = [1 6], Q = [7 8], d = 2", e.p, e.q, e.d)
+	}
+}
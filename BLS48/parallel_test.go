@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSetupCtxProgress(t *testing.T) {
+	l := 8
+	calls := 0
+	last := 0
+	pubKey, mk, err := setupCtx(context.Background(), l, func(done, total int) {
+		calls++
+		if total != 4*l {
+			t.Errorf("progress reported total %d, want %d", total, 4*l)
+		}
+		if done != last+1 {
+			t.Errorf("progress jumped from %d to %d", last, done)
+		}
+		last = done
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 4*l || last != 4*l {
+		t.Errorf("progress called %d times up to %d, want %d", calls, last, 4*l)
+	}
+	if pubKey == nil || mk == nil || len(pubKey.kelements1) != l {
+		t.Error("setupCtx did not return a complete public and master key")
+	}
+}
+
+func TestSetupCtxCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	pubKey, mk, err := setupCtx(ctx, 16, nil)
+	if err != context.Canceled {
+		t.Errorf("got %v for a cancelled setup, want context.Canceled", err)
+	}
+	if pubKey != nil || mk != nil {
+		t.Error("cancelled setupCtx returned keys")
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestQary(t *testing.T) {
+	id := "012321"
+	pubKey, mk, err := setupQ(len(id), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secKey, err := keyGenQ(id, mk, pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := createRandomM(&pk{g1: pubKey.g1, g2: pubKey.g2})
+
+	for _, test := range []struct {
+		s       *subset
+		decrypt bool
+		err     error
+	}{
+		{&subset{cl: "01****", rl: "3*****"}, true, nil}, // d = 1
+		{&subset{cl: "0*23**", rl: "*3*0*0"}, true, nil}, // d = 3
+		{&subset{cl: "******", rl: "0*****"}, false, errRevoked},
+		{&subset{cl: "01****", rl: "0123**"}, false, errRevoked},
+		{&subset{cl: "1*****", rl: "3*****"}, false, nil}, // not covered
+	} {
+		cipher, err := encryptQ(test.s, pubKey, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mes, err := decryptQ(test.s, id, secKey, cipher)
+		if err != test.err {
+			t.Errorf("CL %s, RL %s: got %v, want %v", test.s.cl, test.s.rl, err, test.err)
+			continue
+		}
+		if err == nil && mes.Equals(message) != test.decrypt {
+			t.Errorf("CL %s, RL %s: decrypted %v, want %v", test.s.cl, test.s.rl, !test.decrypt, test.decrypt)
+		}
+	}
+
+	// symbols outside of the alphabet of PK
+	if _, err := keyGenQ("012341", mk, pubKey); err == nil {
+		t.Error("ID with symbol 4 was accepted for k = 4")
+	}
+	if _, err := encryptQ(&subset{cl: "0*****", rl: "*****9"}, pubKey, message); err == nil {
+		t.Error("RL with symbol 9 was accepted for k = 4")
+	}
+	if _, _, err := setupQ(6, 1); err == nil {
+		t.Error("alphabet of size 1 was accepted")
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/miracl/core/go/core/BLS48581"
+)
+
+func TestCompressGT(t *testing.T) {
+	pubKey, _ := setup(4)
+	for _, m := range []*BLS48581.FP48{createRandomM(pubKey), pubKey.omega, BLS48581.NewFP48int(1)} {
+		b := compressGT(m)
+		if len(b) != gtBytes {
+			t.Errorf("compressed element has %d bytes, want %d", len(b), gtBytes)
+		}
+		d, err := decompressGT(b)
+		if err != nil || !d.Equals(m) {
+			t.Errorf("element does not survive compression: %v", err)
+		}
+	}
+
+	b := compressGT(createRandomM(pubKey))
+	if _, err := decompressGT(b[:gtBytes-1]); err != errBadGT {
+		t.Errorf("truncated element: got %v, want errBadGT", err)
+	}
+	flag := append([]byte{}, b...)
+	flag[0] = 2
+	if _, err := decompressGT(flag); err != errBadGT {
+		t.Errorf("unknown flag: got %v, want errBadGT", err)
+	}
+	unity := compressGT(BLS48581.NewFP48int(1))
+	unity[gtBytes-1] = 1
+	if _, err := decompressGT(unity); err != errBadGT {
+		t.Errorf("unity with trailing bytes: got %v, want errBadGT", err)
+	}
+
+	// any other c lies on the torus, but almost surely not in GT
+	other := nonGT(b, 0)
+	d, err := decompressGT(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkGT("m", d) == nil {
+		t.Error("element outside of GT passed checkGT")
+	}
+}
+
+func TestPkFromBytes(t *testing.T) {
+	pubKey, _ := setup(8)
+	raw := pubKey.toBytes()
+	decoded, err := pkFromBytes(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded.toBytes(), raw) || !decoded.omega.Equals(pubKey.omega) {
+		t.Error("public key does not survive serialization")
+	}
+
+	g1, g2 := 4, 4+g1Bytes
+	checkRejected(t, "public key", func(b []byte) error { _, err := pkFromBytes(b); return err }, raw, map[string][]byte{
+		"off-curve g1":        offCurveG1(t, raw, g1),
+		"g2 outside of G2":    nonSubgroupG2(t, raw, g2),
+		"omega outside of GT": nonGT(raw, len(raw)-gtBytes),
+		"l too large":         append([]byte{0xff}, raw[1:]...),
+		"missing last byte":   raw[:len(raw)-1],
+	})
+}
+
+func TestSkFromBytes(t *testing.T) {
+	l := 8
+	pubKey, mk := setup(l)
+	secKey := keyGen(randomID(l), mk, pubKey)
+	raw := secKey.toBytes()
+	decoded, err := skFromBytes(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded.toBytes(), raw) {
+		t.Error("secret key does not survive serialization")
+	}
+
+	x0, z := 4, len(raw)-g2Bytes
+	checkRejected(t, "secret key", func(b []byte) error { _, err := skFromBytes(b); return err }, raw, map[string][]byte{
+		"off-curve x0":      offCurveG1(t, raw, x0),
+		"z outside of G2":   nonSubgroupG2(t, raw, z),
+		"missing last byte": raw[:len(raw)-1],
+	})
+}
+
+func TestHdrFromBytes(t *testing.T) {
+	l := 8
+	pubKey, _ := setup(l)
+	_, s := genSubset(l, 0.5)
+	cipher, _ := encapsulate(s, pubKey)
+	raw := cipher.toBytes()
+	decoded, err := hdrFromBytes(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded.toBytes(), raw) || !decoded.c0.Equals(cipher.c0) {
+		t.Error("header does not survive serialization")
+	}
+
+	c1, c2 := gtBytes, gtBytes+g2Bytes
+	checkRejected(t, "header", func(b []byte) error { _, err := hdrFromBytes(b); return err }, raw, map[string][]byte{
+		"C0 outside of GT":  nonGT(raw, 0),
+		"C1 outside of G2":  nonSubgroupG2(t, raw, c1),
+		"off-curve C2":      offCurveG1(t, raw, c2),
+		"missing last byte": raw[:len(raw)-1],
+	})
+}
+
+// decode every broken input and the valid input with a byte appended, all have to fail
+func checkRejected(t *testing.T, name string, decode func([]byte) error, raw []byte, broken map[string][]byte) {
+	t.Helper()
+	if err := decode(append(append([]byte{}, raw...), 0)); err != errLongInput {
+		t.Errorf("%s with a byte appended: got %v, want errLongInput", name, err)
+	}
+	for what, b := range broken {
+		if err := decode(b); err == nil {
+			t.Errorf("%s with %s was accepted", name, what)
+		}
+	}
+}
+
+// copy of b with the x coordinate of the G1 element at off changed to one that is not on the curve
+func offCurveG1(t *testing.T, b []byte, off int) []byte {
+	t.Helper()
+	c := append([]byte{}, b...)
+	for i := 0; i < 256; i++ {
+		c[off+g1Bytes-1] = byte(i)
+		if BLS48581.ECP_fromBytes(c[off : off+g1Bytes]).Is_infinity() {
+			return c
+		}
+	}
+	t.Fatal("no x coordinate off the curve found")
+	return nil
+}
+
+// copy of b with the G2 element at off changed to a point of the twist outside of G2
+// G2 has a cofactor on every curve, so almost every point of the twist qualifies
+func nonSubgroupG2(t *testing.T, b []byte, off int) []byte {
+	t.Helper()
+	c := append([]byte{}, b...)
+	for i := 0; i < 256; i++ {
+		c[off+g2Bytes-1] = byte(i)
+		P := BLS48581.ECP8_fromBytes(c[off : off+g2Bytes])
+		if !P.Is_infinity() && !BLS48581.G2member(P) {
+			return c
+		}
+	}
+	t.Fatal("no point outside of G2 found")
+	return nil
+}
+
+// copy of b with the compressed GT element at off changed to another point of the torus
+func nonGT(b []byte, off int) []byte {
+	c := append([]byte{}, b...)
+	c[off+gtBytes-1] ^= 1
+	return c
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+)
+
+func TestStreamRoundTrip(t *testing.T) {
+	aead := testAEAD(t)
+	prefix := []byte("prefix!")
+	ad := []byte("preamble")
+
+	for _, size := range []int{0, 1, chunkBytes - 1, chunkBytes, 2 * chunkBytes, 2*chunkBytes + 7} {
+		plain := make([]byte, size)
+		for i := range plain {
+			plain[i] = rng.GetByte()
+		}
+		sealed := seal(t, aead, prefix, ad, plain)
+		if want := size + (size/chunkBytes+1)*aead.Overhead(); size%chunkBytes != 0 && len(sealed) != want {
+			t.Errorf("size %d: sealed %d bytes, want %d", size, len(sealed), want)
+		}
+
+		var out bytes.Buffer
+		if err := openStream(aead, prefix, ad, bytes.NewReader(sealed), &out); err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if !bytes.Equal(out.Bytes(), plain) {
+			t.Errorf("size %d: plaintext changed", size)
+		}
+		if err := openStream(aead, prefix, []byte("other"), bytes.NewReader(sealed), &out); err != errCorrupted {
+			t.Errorf("size %d: opened with other additional data: %v", size, err)
+		}
+	}
+}
+
+func TestStreamTampering(t *testing.T) {
+	aead := testAEAD(t)
+	prefix := []byte("prefix!")
+	plain := make([]byte, 3*chunkBytes)
+	sealed := seal(t, aead, prefix, nil, plain)
+	sealedChunk := chunkBytes + aead.Overhead()
+
+	flipped := append([]byte{}, sealed...)
+	flipped[sealedChunk+5] ^= 1
+	swapped := append([]byte{}, sealed[sealedChunk:2*sealedChunk]...)
+	swapped = append(swapped, sealed[:sealedChunk]...)
+	swapped = append(swapped, sealed[2*sealedChunk:]...)
+
+	cases := map[string][]byte{
+		"empty":          {},
+		"flipped bit":    flipped,
+		"swapped chunks": swapped,
+		"cut at chunk":   sealed[:2*sealedChunk],
+		"cut in chunk":   sealed[:len(sealed)-1],
+		"appended":       append(append([]byte{}, sealed...), sealed[:sealedChunk]...),
+	}
+	for name, b := range cases {
+		if err := openStream(aead, prefix, nil, bytes.NewReader(b), &bytes.Buffer{}); err != errCorrupted {
+			t.Errorf("%s: got %v, want errCorrupted", name, err)
+		}
+	}
+}
+
+// AEAD under a fixed key
+func testAEAD(t *testing.T) cipher.AEAD {
+	block, err := aes.NewCipher(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aead
+}
+
+// plain sealed with sealStream
+func seal(t *testing.T, aead cipher.AEAD, prefix []byte, ad []byte, plain []byte) []byte {
+	var sealed bytes.Buffer
+	if err := sealStream(aead, prefix, ad, bytes.NewReader(plain), &sealed); err != nil {
+		t.Fatal(err)
+	}
+	return sealed.Bytes()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+)
+
+// func main() {
+
+// 	// Initialise Random number generator
+// 	initRNG()
+
+// 	testExtension("01101010", "011010101100", "*1****10****", "*****110****")
+// }
+
+// Function to extend the ID length of a running system from len(oldID) to len(newID)
+// and decrypt one header with a key from before and one from after the extension
+func testExtension(oldID string, newID string, cl string, rl string) {
+
+	fmt.Println("\n")
+	fmt.Println("-------  ID Length Extension  ---------")
+	fmt.Println("Extending from ", len(oldID), " to ", len(newID), " bits")
+
+	pubKey, mk := setup(len(oldID))
+	oldKey := keyGen(oldID, mk, pubKey)
+
+	extended, ext, err := extendPK(pubKey, len(newID))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Is the extended public key valid? ", extended.Validate() == nil)
+
+	up, err := ext.upgrade(extended, oldKey.y0, oldKey.z)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	key := newUpgradedKey(oldKey)
+	if err := key.apply(up); err != nil {
+		fmt.Println(err)
+		return
+	}
+	newKey := keyGen(newID, mk, extended)
+
+	s := &subset{cl, rl}
+	message := createRandomM(extended)
+	cipher := encrypt(s, extended, message)
+
+	mes, err := decryptUpgraded(s, oldID, key, cipher)
+	fmt.Println("Upgraded key decrypts: ", err == nil && message.Equals(mes))
+	mes, err = decrypt(s, newID, newKey, cipher)
+	fmt.Println("New key decrypts: ", err == nil && message.Equals(mes))
+}
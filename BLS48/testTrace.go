@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/miracl/core/go/core/BLS48581"
+)
+
+// func main() {
+
+// 	// Initialise Random number generator
+// 	initRNG()
+
+// 	testTracing(8, []string{"01101010", "11101110"}, 10)
+// }
+
+// Function to build a pirate decoder from the keys of traitors and trace it
+func testTracing(l int, traitors []string, trials int) {
+
+	fmt.Println("\n")
+	fmt.Println("-------  Traitor Tracing  ---------")
+
+	pubKey, mk := setup(l)
+	keys := make([]*sk, len(traitors))
+	for j, id := range traitors {
+		keys[j] = keyGen(id, mk, pubKey)
+	}
+
+	results, queries, err := traceTraitors(pubKey, newPirate(traitors, keys), trials)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Traitors: ", traitors, " Queries: ", queries)
+	for _, res := range results {
+		fmt.Println("Suspect: ", res.id, " Confidence: ", res.confidence)
+	}
+}
+
+// simulated pirate decoder, decrypts every header with one of the keys chosen at random
+func newPirate(ids []string, keys []*sk) pirateDecoder {
+	return func(s *subset, cipher *hdr) *BLS48581.FP48 {
+		j := int(rng.GetByte()) % len(keys)
+		mes, err := decrypt(s, ids[j], keys[j], cipher)
+		if err != nil {
+			return nil
+		}
+		return mes
+	}
+}
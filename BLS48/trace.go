@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/miracl/core/go/core/BLS48581"
+)
+
+// ----------- Traitor Tracing
+// A pirate decoder is treated as a black box that gets a subset and a header
+// and returns the message if it can decrypt it. The tracer searches the tree of
+// ID prefixes: the header for prefix P has CL = P*...* and RL fixed only at the
+// last position of P, to the opposite bit, so it is decryptable by exactly the
+// IDs starting with P. Prefixes the pirate can decrypt are extended bit by bit
+// until full IDs are reached.
+//
+// A pirate that uses several keys, or a random one per header, decrypts a prefix
+// only in some of the trials, so every prefix is tested trials times and kept if
+// at least one trial succeeds. Pirates that detect tracing headers and stop
+// working are not handled.
+
+// ----------- Structs
+
+// pirate decoder as an oracle, returns nil if it can not decrypt the header
+type pirateDecoder func(s *subset, cipher *hdr) *BLS48581.FP48
+
+// suspected ID whose key is in the pirate decoder
+// confidence is the share of trials the pirate decrypted for the full ID
+type traceResult struct {
+	id         string
+	confidence float64
+}
+
+// Trace the keys used by pirate, testing every prefix trials times
+// returns the suspected IDs and the number of headers sent to the pirate
+func traceTraitors(pubKey *pk, pirate pirateDecoder, trials int) ([]*traceResult, int, error) {
+	if trials < 1 {
+		return nil, 0, errors.New("ERROR: tracing needs at least one trial per prefix")
+	}
+
+	l := len(pubKey.helements0)
+	queries := 0
+	results := []*traceResult{}
+
+	prefixes := []string{"0", "1"}
+	for len(prefixes) > 0 {
+		prefix := prefixes[len(prefixes)-1]
+		prefixes = prefixes[:len(prefixes)-1]
+
+		s := prefixSubset(prefix, l)
+		hits := 0
+		for i := 0; i < trials; i++ {
+			cipher, message := encapsulate(s, pubKey)
+			mes := pirate(s, cipher)
+			if mes != nil && mes.Equals(message) {
+				hits++
+			}
+		}
+		queries += trials
+
+		if hits == 0 {
+			continue
+		}
+		if len(prefix) == l {
+			results = append(results, &traceResult{prefix, float64(hits) / float64(trials)})
+			continue
+		}
+		prefixes = append(prefixes, prefix+"1", prefix+"0")
+	}
+	return results, queries, nil
+}
+
+// subset covering exactly the IDs of length l that start with prefix
+func prefixSubset(prefix string, l int) *subset {
+	cl := []byte(prefix)
+	rl := make([]byte, len(prefix))
+	for i := 0; i < len(prefix); i++ {
+		rl[i] = '*'
+	}
+	last := len(prefix) - 1
+	rl[last] = '0' + '1' - prefix[last]
+	for i := len(prefix); i < l; i++ {
+		cl = append(cl, '*')
+		rl = append(rl, '*')
+	}
+	return &subset{string(cl), string(rl)}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/miracl/core/go/core/BLS48581"
+)
+
+func TestVerifyKey(t *testing.T) {
+	l := 4
+	pubKey, mk := setup(l)
+	id := randomID(l)
+	q := BLS48581.NewBIGints(BLS48581.CURVE_Order)
+	other := BLS48581.G1mul(pubKey.g1, BLS48581.Randomnum(q, rng))
+
+	if err := verifyKey(pubKey, id, keyGen(id, mk, pubKey)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func(secKey *sk)
+		want    keyComponentError
+	}{
+		{"y0", func(secKey *sk) { secKey.y0 = other }, keyComponentError{"y", 0}},
+		{"z", func(secKey *sk) { secKey.z = BLS48581.G2mul(pubKey.g2, BLS48581.Randomnum(q, rng)) }, keyComponentError{"z", -1}},
+		{"x2", func(secKey *sk) { secKey.xelements[1] = other }, keyComponentError{"x", 2}},
+		{"y4", func(secKey *sk) { secKey.yEven[1] = other }, keyComponentError{"y", 4}},
+		{"y3", func(secKey *sk) { secKey.yOdd[1] = other }, keyComponentError{"y", 3}},
+		{"x0", func(secKey *sk) { secKey.x0 = other }, keyComponentError{"x", 0}},
+	}
+	for _, test := range tests {
+		secKey := keyGen(id, mk, pubKey)
+		test.corrupt(secKey)
+		err := verifyKey(pubKey, id, secKey)
+		if got, ok := err.(*keyComponentError); !ok || *got != test.want {
+			t.Errorf("corrupted %s: got %v, want %v", test.name, err, &test.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/miracl/core/go/core/BN254"
+)
+
+// ----------- Structs
+
+// header queued for a device, together with the subset it was sent to
+type queuedHdr struct {
+	s      *subset
+	cipher *hdr
+}
+
+// Batch Decrypt for one device: (S, Hdr) pairs -> M or error for each pair, in the same order
+// Headers are grouped by subset, so the key material of Decrypt 1 - 4 is only
+// aggregated once per distinct subset. The pairings then run concurrently.
+func decryptBatch(id string, secKey *sk, queue []*queuedHdr) (mes []*BN254.FP12, errs []error) {
+	mes = make([]*BN254.FP12, len(queue))
+	errs = make([]error, len(queue))
+
+	// group headers by subset
+	subsets := []*subset{}
+	seen := make(map[subset]bool)
+	for _, q := range queue {
+		if q == nil || q.s == nil || q.cipher == nil {
+			continue
+		}
+		if !seen[*q.s] {
+			seen[*q.s] = true
+			subsets = append(subsets, q.s)
+		}
+	}
+
+	// aggregate key material once per subset
+	dec, err := newDecryptor(id, secKey, len(subsets))
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return mes, errs
+	}
+	parallelFor(len(subsets), func(i int) {
+		dec.entry(subsets[i])
+	})
+
+	// pairings for every header
+	parallelFor(len(queue), func(i int) {
+		q := queue[i]
+		if q == nil || q.s == nil || q.cipher == nil {
+			errs[i] = errors.New("ERROR: header or subset missing")
+			return
+		}
+		mes[i], errs[i] = dec.decrypt(q.s, q.cipher)
+	})
+
+	return mes, errs
+}
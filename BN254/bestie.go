@@ -0,0 +1,508 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/miracl/core/go/core/BN254"
+)
+
+// ----------- Command Line Tool
+// bestie setup|keygen|encrypt|decrypt|inspect [flags]
+//
+// Keys and ciphertexts are read from and written to files in the formats of
+// serialize.go, "-" stands for stdin or stdout. encrypt encapsulates a random M
+// for CL and RL and streams its input through AES-256-GCM under the key derived
+// from M in chunks (see stream.go), decrypt reverses that.
+//
+// A ciphertext file is the preamble: magic, CL, RL, the header and the key
+// confirmation value of confirm.go, each with a 4 byte length, and the nonce
+// prefix; followed by the sealed chunks, which authenticate the whole preamble.
+//
+// Every folder is built with -tags bestie into bestie-<curveName>, and
+// cmd/bestie runs the one for the curve given with -curve. -curve is checked
+// here as well, so a binary can not be used for files of another curve.
+
+// exit codes of the command line tool
+const (
+	exitOK      = 0
+	exitError   = 1 // I/O errors, invalid files
+	exitUsage   = 2 // unknown subcommands or flags, wrong curve
+	exitDecrypt = 3 // the device can not decrypt: not covered, revoked or wrong key
+)
+
+// curve of this folder
+const curveName = "BN254"
+
+// magic number at the start of files written by encrypt
+const ctMagic = "BSTC"
+
+// nonce size of AES-GCM
+const nonceBytes = 12
+
+// upper bound for the length of CL, RL and the header in a ciphertext file
+const maxFieldBytes = 1 << 20
+
+var errUsage = errors.New("usage: bestie setup|keygen|encrypt|decrypt|inspect [flags], see bestie <command> -h")
+
+// Run the subcommand in args and return the exit code
+func bestieMain(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, errUsage)
+		return exitUsage
+	}
+
+	cmds := map[string]func([]string, *cliIO) error{
+		"setup":   cmdSetup,
+		"keygen":  cmdKeyGen,
+		"encrypt": cmdEncrypt,
+		"decrypt": cmdDecrypt,
+		"inspect": cmdInspect,
+	}
+	cmd, ok := cmds[args[0]]
+	if !ok {
+		fmt.Fprintln(stderr, errUsage)
+		return exitUsage
+	}
+
+	err := cmd(args[1:], &cliIO{stdin, stdout, stderr})
+	switch {
+	case err == nil:
+		return exitOK
+	case err == flag.ErrHelp:
+		return exitOK
+	case errors.As(err, new(*usageError)):
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	case err == errRevoked || err == errWrongKey || err == errLength:
+		fmt.Fprintln(stderr, err)
+		return exitDecrypt
+	}
+	fmt.Fprintln(stderr, err)
+	return exitError
+}
+
+// ----------- Structs
+
+type cliIO struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// error in the command line, gives exitUsage
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// flag set of a subcommand with the common -curve flag
+func newFlags(name string, cio *cliIO) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet("bestie "+name, flag.ContinueOnError)
+	flags.SetOutput(cio.stderr)
+	curve := flags.String("curve", curveName, "curve, has to be the one this binary was built for")
+	return flags, curve
+}
+
+// parse args and check -curve
+func parseFlags(flags *flag.FlagSet, curve *string, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return &usageError{err.Error()}
+	}
+	if flags.NArg() > 0 {
+		return &usageError{fmt.Sprintf("unexpected argument %q", flags.Arg(0))}
+	}
+	if *curve != curveName {
+		return &usageError{fmt.Sprintf("this binary is built for %s, use the one from the %s folder", curveName, *curve)}
+	}
+	return nil
+}
+
+// bestie setup -l 8 -pk pk.bin -mk mk.bin
+func cmdSetup(args []string, cio *cliIO) error {
+	flags, curve := newFlags("setup", cio)
+	l := flags.Int("l", 8, "ID bit length")
+	pkFile := flags.String("pk", "-", "output file for the public key")
+	mkFile := flags.String("mk", "", "output file for the master key (required)")
+	if err := parseFlags(flags, curve, args); err != nil {
+		return err
+	}
+	if *l < 1 {
+		return &usageError{"-l must be at least 1"}
+	}
+	if *mkFile == "" || *mkFile == "-" {
+		return &usageError{"-mk has to name a file, the master key is not written to stdout"}
+	}
+
+	// never overwrite a master key, nor reuse a file others can read
+	f, err := os.OpenFile(*mkFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	pubKey, mk := setup(*l)
+	lk := cio.lockMK(mk)
+	defer lk.Destroy()
+
+	// the serialised form of lockMK is the file format
+	_, err = f.Write(lk.buf)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(*mkFile)
+		return err
+	}
+	return cio.write(*pkFile, pubKey.toBytes(), 0644)
+}
+
+// bestie keygen -pk pk.bin -mk mk.bin -id 01101010 -out sk.bin
+func cmdKeyGen(args []string, cio *cliIO) error {
+	flags, curve := newFlags("keygen", cio)
+	pkFile := flags.String("pk", "", "public key file (required)")
+	mkFile := flags.String("mk", "", "master key file (required)")
+	id := flags.String("id", "", "device ID (required)")
+	out := flags.String("out", "-", "output file for the secret key")
+	if err := parseFlags(flags, curve, args); err != nil {
+		return err
+	}
+	if *pkFile == "" || *mkFile == "" || *id == "" {
+		return &usageError{"-pk, -mk and -id are required"}
+	}
+
+	pubKey, err := cio.readPK(*pkFile)
+	if err != nil {
+		return err
+	}
+	if err := checkID(*id, len(pubKey.helements0)); err != nil {
+		return &usageError{err.Error()}
+	}
+	raw, err := cio.read(*mkFile)
+	if err != nil {
+		return err
+	}
+	if len(raw) != g1Bytes {
+		wipeBytes(raw)
+		return errors.New("ERROR: master key file has the wrong size")
+	}
+	lk := cio.lockMK(BN254.ECP_fromBytes(raw))
+	wipeBytes(raw)
+	defer lk.Destroy()
+
+	mk := lk.get()
+	defer wipeECP(mk)
+	if err := checkG1("MK", -1, mk); err != nil {
+		return err
+	}
+
+	secKey := keyGen(*id, mk, pubKey)
+	defer secKey.Destroy()
+	buf := secKey.toBytes()
+	defer wipeBytes(buf)
+	return cio.write(*out, buf, 0600)
+}
+
+// bestie encrypt -pk pk.bin -cl '*1****10' -rl '*****110' < plain > ct.bin
+func cmdEncrypt(args []string, cio *cliIO) error {
+	flags, curve := newFlags("encrypt", cio)
+	pkFile := flags.String("pk", "", "public key file (required)")
+	cl := flags.String("cl", "", "list of covered IDs (required)")
+	rl := flags.String("rl", "", "list of revoked IDs (required)")
+	in := flags.String("in", "-", "input file")
+	out := flags.String("out", "-", "output file")
+	if err := parseFlags(flags, curve, args); err != nil {
+		return err
+	}
+	if *pkFile == "" || *cl == "" || *rl == "" {
+		return &usageError{"-pk, -cl and -rl are required"}
+	}
+
+	pubKey, err := cio.readPK(*pkFile)
+	if err != nil {
+		return err
+	}
+	s := &subset{*cl, *rl}
+	if err := checkSubset(s, len(pubKey.helements0)); err != nil {
+		return &usageError{err.Error()}
+	}
+	input, err := cio.open(*in)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	cipher, message := encapsulate(s, pubKey)
+	aead, check, err := sealKeys(message)
+	if err != nil {
+		return err
+	}
+	prefix := make([]byte, noncePrefixBytes)
+	for i := range prefix {
+		prefix[i] = rng.GetByte()
+	}
+
+	// magic, CL, RL, header, confirmation value, nonce prefix
+	preamble := []byte(ctMagic)
+	for _, field := range [][]byte{[]byte(s.cl), []byte(s.rl), cipher.toBytes(), check} {
+		preamble = appendLen(preamble, len(field))
+		preamble = append(preamble, field...)
+	}
+	preamble = append(preamble, prefix...)
+
+	return cio.create(*out, 0644, func(w io.Writer) error {
+		if _, err := w.Write(preamble); err != nil {
+			return err
+		}
+		return sealStream(aead, prefix, preamble, input, w)
+	})
+}
+
+// bestie decrypt -sk sk.bin -id 01101010 < ct.bin > plain
+func cmdDecrypt(args []string, cio *cliIO) error {
+	flags, curve := newFlags("decrypt", cio)
+	skFile := flags.String("sk", "", "secret key file (required)")
+	id := flags.String("id", "", "device ID (required)")
+	in := flags.String("in", "-", "input file")
+	out := flags.String("out", "-", "output file")
+	if err := parseFlags(flags, curve, args); err != nil {
+		return err
+	}
+	if *skFile == "" || *id == "" {
+		return &usageError{"-sk and -id are required"}
+	}
+
+	raw, err := cio.read(*skFile)
+	if err != nil {
+		return err
+	}
+	secKey, err := skFromBytes(raw)
+	wipeBytes(raw)
+	if err != nil {
+		return err
+	}
+	defer secKey.Destroy()
+	if err := checkID(*id, len(secKey.xelements)); err != nil {
+		return &usageError{err.Error()}
+	}
+
+	input, err := cio.open(*in)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+	br := bufio.NewReader(input)
+	ct, err := readCiphertext(br)
+	if err != nil {
+		return err
+	}
+
+	message, err := decrypt(ct.s, *id, secKey, ct.cipher)
+	if err != nil {
+		fmt.Fprintln(cio.stderr, explain(*id, ct.s))
+		return err
+	}
+	aead, err := openKeys(message, ct.check)
+	if err != nil {
+		fmt.Fprintln(cio.stderr, explain(*id, ct.s))
+		return err
+	}
+	return cio.create(*out, 0600, func(w io.Writer) error {
+		return openStream(aead, ct.prefix, ct.preamble, br, w)
+	})
+}
+
+// bestie inspect -in file [-id 01101010]
+// prints what kind of file it is and its parameters, with -id also explain for ciphertexts
+func cmdInspect(args []string, cio *cliIO) error {
+	flags, curve := newFlags("inspect", cio)
+	in := flags.String("in", "-", "file to inspect")
+	id := flags.String("id", "", "device ID to explain a ciphertext for")
+	if err := parseFlags(flags, curve, args); err != nil {
+		return err
+	}
+	buf, err := cio.read(*in)
+	if err != nil {
+		return err
+	}
+	w := cio.stdout
+
+	r := bytes.NewReader(buf)
+	if ct, err := readCiphertext(r); err == nil {
+		fmt.Fprintf(w, "%s ciphertext, %d bytes\nCL: %s\nRL: %s\nsealed payload: %d bytes in chunks of %d\n",
+			curveName, len(buf), ct.s.cl, ct.s.rl, r.Len(), chunkBytes)
+		if *id != "" {
+			if err := checkID(*id, len(ct.s.cl)); err != nil {
+				return &usageError{err.Error()}
+			}
+			fmt.Fprintln(w, explain(*id, ct.s))
+		}
+		return nil
+	}
+	if pubKey, err := pkFromBytes(buf); err == nil {
+		fmt.Fprintf(w, "%s public key, %d bytes, l = %d\n", curveName, len(buf), len(pubKey.helements0))
+		return nil
+	}
+	if secKey, err := skFromBytes(buf); err == nil {
+		fmt.Fprintf(w, "%s secret key, %d bytes, l = %d\n", curveName, len(buf), len(secKey.xelements))
+		secKey.Destroy()
+		return nil
+	}
+	if _, err := hdrFromBytes(buf); err == nil {
+		fmt.Fprintf(w, "%s header, %d bytes\n", curveName, len(buf))
+		return nil
+	}
+	if len(buf) == g1Bytes && checkG1("MK", -1, BN254.ECP_fromBytes(buf)) == nil {
+		fmt.Fprintf(w, "%s master key, %d bytes\n", curveName, len(buf))
+		return nil
+	}
+	return fmt.Errorf("ERROR: not a %s key, header or ciphertext", curveName)
+}
+
+// preamble of a file written by encrypt
+type ciphertext struct {
+	s        *subset
+	cipher   *hdr
+	check    []byte
+	preamble []byte // everything up to the first chunk, authenticated by every chunk
+	prefix   []byte
+}
+
+// read the preamble of a ciphertext file, r is left at the first chunk
+func readCiphertext(r io.Reader) (*ciphertext, error) {
+	preamble := make([]byte, len(ctMagic))
+	if _, err := io.ReadFull(r, preamble); err != nil || string(preamble) != ctMagic {
+		return nil, errors.New("ERROR: not a ciphertext")
+	}
+
+	fields := make([][]byte, 4) // CL, RL, header, confirmation value
+	for i := range fields {
+		var n [4]byte
+		if _, err := io.ReadFull(r, n[:]); err != nil {
+			return nil, errShortInput
+		}
+		size := binary.BigEndian.Uint32(n[:])
+		if size > maxFieldBytes {
+			return nil, errors.New("ERROR: ciphertext field too long")
+		}
+		fields[i] = make([]byte, size)
+		if _, err := io.ReadFull(r, fields[i]); err != nil {
+			return nil, errShortInput
+		}
+		preamble = append(append(preamble, n[:]...), fields[i]...)
+	}
+	prefix := make([]byte, noncePrefixBytes)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, errShortInput
+	}
+	preamble = append(preamble, prefix...)
+
+	cipher, err := hdrFromBytes(fields[2])
+	if err != nil {
+		return nil, err
+	}
+	s := &subset{string(fields[0]), string(fields[1])}
+	if err := checkSubset(s, len(s.cl)); err != nil {
+		return nil, err
+	}
+	return &ciphertext{s, cipher, fields[3], preamble, prefix}, nil
+}
+
+// read a whole file, "-" is stdin
+func (cio *cliIO) read(path string) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(cio.stdin)
+	}
+	return ioutil.ReadFile(path)
+}
+
+// write a whole file, "-" is stdout
+func (cio *cliIO) write(path string, b []byte, perm os.FileMode) error {
+	if path == "-" {
+		_, err := cio.stdout.Write(b)
+		return err
+	}
+	f, err := openOutput(path, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// truncate or create the file at path with permissions perm
+// an existing file gets perm as well, so secrets never end up in a file others can read
+func openOutput(path string, perm os.FileMode) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// open a file for streaming, "-" is stdin
+func (cio *cliIO) open(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return ioutil.NopCloser(cio.stdin), nil
+	}
+	return os.Open(path)
+}
+
+// create a file and stream into it with write, "-" is stdout
+// if write fails the file is removed, so no partial output is left behind
+func (cio *cliIO) create(path string, perm os.FileMode, write func(w io.Writer) error) error {
+	if path == "-" {
+		return write(cio.stdout)
+	}
+	f, err := openOutput(path, perm)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	err = write(bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+func (cio *cliIO) readPK(path string) (*pk, error) {
+	raw, err := cio.read(path)
+	if err != nil {
+		return nil, err
+	}
+	return pkFromBytes(raw)
+}
+
+// move mk into locked memory, warning on stderr if it has to stay on the heap
+func (cio *cliIO) lockMK(mk *BN254.ECP) *lockedMK {
+	lk, err := lockMK(mk)
+	if err != nil {
+		fmt.Fprintln(cio.stderr, "WARNING: master key is not in locked memory:", err)
+	}
+	return lk
+}
@@ -0,0 +1,13 @@
+//go:build bestie
+// +build bestie
+
+package main
+
+import "os"
+
+// main of the command line tool, built with go build -tags bestie
+// the main of the test programs is excluded by the same tag
+func main() {
+	initRNG()
+	os.Exit(bestieMain(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
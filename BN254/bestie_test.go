@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// devices of the tests below for CL *1****10 and RL *****110
+const (
+	cliID        = "01101010" // covered
+	cliRevoked   = "01101110" // covered, but revoked
+	cliUncovered = "00101010" // not covered
+)
+
+func TestBestieRoundTrip(t *testing.T) {
+	file := bestieKeys(t, cliID)
+	plain := randomBytes(chunkBytes + 100)
+	if err := ioutil.WriteFile(file("plain"), plain, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	runBestie(t, nil, exitOK, "encrypt", "-pk", file("pk"), "-cl", "*1****10", "-rl", "*****110", "-in", file("plain"), "-out", file("ct"))
+	runBestie(t, nil, exitOK, "decrypt", "-sk", file(cliID), "-id", cliID, "-in", file("ct"), "-out", file("out"))
+	out, err := ioutil.ReadFile(file("out"))
+	if err != nil || !bytes.Equal(out, plain) {
+		t.Fatalf("decrypted file differs: %v", err)
+	}
+
+	runBestie(t, nil, exitUsage, "decrypt", "-sk", file(cliID), "-id", "0110101x", "-in", file("ct"), "-out", file("bad"))
+	runBestie(t, nil, exitUsage, "decrypt", "-sk", file(cliID), "-id", "0110", "-in", file("ct"), "-out", file("bad"))
+	runBestie(t, nil, exitUsage, "inspect", "-in", file("ct"), "-id", "0110")
+
+	ct, _ := ioutil.ReadFile(file("ct"))
+	if err := ioutil.WriteFile(file("cut"), ct[:len(ct)-1], 0600); err != nil {
+		t.Fatal(err)
+	}
+	runBestie(t, nil, exitError, "decrypt", "-sk", file(cliID), "-id", cliID, "-in", file("cut"), "-out", file("bad"))
+	if _, err := os.Stat(file("bad")); !os.IsNotExist(err) {
+		t.Errorf("decrypt left output behind: %v", err)
+	}
+}
+
+func TestBestiePipes(t *testing.T) {
+	file := bestieKeys(t, cliID)
+	plain := randomBytes(3*chunkBytes + 1)
+
+	ct := runBestie(t, plain, exitOK, "encrypt", "-pk", file("pk"), "-cl", "*1****10", "-rl", "*****110")
+	out := runBestie(t, ct, exitOK, "decrypt", "-sk", file(cliID), "-id", cliID)
+	if !bytes.Equal(out, plain) {
+		t.Error("piped plaintext differs")
+	}
+
+	// the public key can come from stdin as well
+	pubKey, _ := ioutil.ReadFile(file("pk"))
+	secKey := runBestie(t, pubKey, exitOK, "keygen", "-pk", "-", "-mk", file("mk"), "-id", cliID)
+	if err := ioutil.WriteFile(file("sk2"), secKey, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if out := runBestie(t, ct, exitOK, "decrypt", "-sk", file("sk2"), "-id", cliID); !bytes.Equal(out, plain) {
+		t.Error("plaintext differs with a key written to stdout")
+	}
+}
+
+func TestBestieCannotDecrypt(t *testing.T) {
+	file := bestieKeys(t, cliID, cliRevoked, cliUncovered)
+	ct := runBestie(t, randomBytes(100), exitOK, "encrypt", "-pk", file("pk"), "-cl", "*1****10", "-rl", "*****110")
+
+	cases := map[string][]string{
+		"revoked":     {"-sk", file(cliRevoked), "-id", cliRevoked},
+		"not covered": {"-sk", file(cliUncovered), "-id", cliUncovered},
+		"wrong key":   {"-sk", file(cliUncovered), "-id", cliID},
+	}
+	for name, args := range cases {
+		args = append([]string{"decrypt", "-out", file("out")}, args...)
+		runBestie(t, ct, exitDecrypt, args...)
+		if _, err := os.Stat(file("out")); !os.IsNotExist(err) {
+			t.Errorf("%s: decrypt wrote output: %v", name, err)
+		}
+	}
+}
+
+func TestBestieKeyFiles(t *testing.T) {
+	file := bestieKeys(t, cliID)
+
+	// an existing master key is never overwritten
+	mk, _ := ioutil.ReadFile(file("mk"))
+	runBestie(t, nil, exitError, "setup", "-l", "8", "-pk", file("pk2"), "-mk", file("mk"))
+	if again, _ := ioutil.ReadFile(file("mk")); !bytes.Equal(again, mk) {
+		t.Error("setup overwrote the master key")
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if info, err := os.Stat(file("mk")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("master key file has mode %v: %v", info.Mode(), err)
+	}
+	// a secret key written over a readable file takes its permissions away
+	if err := ioutil.WriteFile(file("open"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	runBestie(t, nil, exitOK, "keygen", "-pk", file("pk"), "-mk", file("mk"), "-id", cliID, "-out", file("open"))
+	if info, err := os.Stat(file("open")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("secret key file has mode %v: %v", info.Mode(), err)
+	}
+}
+
+// run setup for l = 8 and keygen for ids in a temporary directory
+// the returned function gives the path of a file there, the secret key of an ID is named after it
+func bestieKeys(t *testing.T, ids ...string) func(name string) string {
+	t.Helper()
+	dir := t.TempDir()
+	file := func(name string) string { return filepath.Join(dir, name) }
+
+	runBestie(t, nil, exitOK, "setup", "-l", "8", "-pk", file("pk"), "-mk", file("mk"))
+	for _, id := range ids {
+		runBestie(t, nil, exitOK, "keygen", "-pk", file("pk"), "-mk", file("mk"), "-id", id, "-out", file(id))
+	}
+	return file
+}
+
+// run the command line tool with args and stdin, check its exit code and return its stdout
+func runBestie(t *testing.T, stdin []byte, want int, args ...string) []byte {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	if got := bestieMain(args, bytes.NewReader(stdin), &stdout, &stderr); got != want {
+		t.Fatalf("bestie %v: exit code %d, want %d\n%s", args, got, want, stderr.String())
+	}
+	return stdout.Bytes()
+}
+
+// n random bytes
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = rng.GetByte()
+	}
+	return b
+}
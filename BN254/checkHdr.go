@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errMalformedHdr = errors.New("ERROR: header is not consistent with the subset and public key")
+
+// Public header check (PK, S=(CL,RL), HdrS) -> error
+// Needs no secret key, so gateways can drop garbage before it reaches devices.
+// C1 = g2^t fixes t, the header is well-formed if C2 and C3 use the same t:
+//
+//	e(C2, g2) = e(H(CL), C1)
+//	e(C3, g2) = e(K(RL), C1)
+func checkHdr(pubKey *pk, s *subset, cipher *hdr) error {
+	if err := checkSubset(s, len(pubKey.helements0)); err != nil {
+		return err
+	}
+	if err := cipher.Validate(); err != nil {
+		return err
+	}
+
+	if !pairsTo(cipher.c2, pubKey.g2, aggregateH(s.cl, pubKey), cipher.c1, nil) {
+		return errMalformedHdr
+	}
+	if !pairsTo(cipher.c3, pubKey.g2, aggregateK(s.rl, pubKey), cipher.c1, nil) {
+		return errMalformedHdr
+	}
+	return nil
+}
+
+// check that CL and RL have length l and only contain 0, 1 and *
+func checkSubset(s *subset, l int) error {
+	if len(s.cl) != l || len(s.rl) != l {
+		return fmt.Errorf("ERROR: CL and RL must have length %d", l)
+	}
+	for i := 0; i < l; i++ {
+		if s.cl[i] != '0' && s.cl[i] != '1' && s.cl[i] != '*' {
+			return errors.New("CL could not be read")
+		}
+		if s.rl[i] != '0' && s.rl[i] != '1' && s.rl[i] != '*' {
+			return errors.New("RL could not be read")
+		}
+	}
+	return nil
+}
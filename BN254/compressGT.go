@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/miracl/core/go/core/BN254"
+)
+
+// ----------- GT Compression
+// Elements of GT are unitary: m * conj(m) = 1. Writing FP12 as FP6[sigma] with
+// sigma^2 in FP6, m = A + B*sigma with A, B in FP6, and m != 1 is given by the
+// single FP6 element c = (1 + A) / B of the torus T2:
+//
+//	m = (c + sigma) / (c - sigma)
+//
+// so half of the FP12 coefficients are enough. Compressed elements take one
+// flag byte, 1 for m = 1 and 0 otherwise, followed by c.
+//
+// MIRACL has no accessors for the coefficients, so they are taken from
+// FP12.ToBytes, which writes c, b, a of FP12 = FP4^3 and b, a of every FP4 = FP2^2.
+// With w^3 = s for the generators w of FP12 over FP4 and s of FP4 over FP2,
+// sigma = w^3 = s and the FP6 part of m are the coefficients of 1, w^2 and w^4.
+
+const gtCoeffBytes = 2 * fpBytes // FP2
+
+// positions of the coefficients in gtCoeffs
+const (
+	cw5 = iota // c1: w^5
+	cw2        // c0: w^2
+	cw4        // b1: w^4
+	cw1        // b0: w
+	cw3        // a1: w^3 = sigma
+	cw0        // a0: 1
+)
+
+var errBadGT = errors.New("ERROR: invalid compressed GT element")
+
+// compress a GT element to gtBytes
+func compressGT(m *BN254.FP12) []byte {
+	buf := make([]byte, gtBytes)
+	if m.Isunity() {
+		buf[0] = 1
+		return buf
+	}
+
+	zero := BN254.NewFP2int(0)
+	k := gtCoeffs(m)
+
+	// 1 + A and B*sigma
+	one := BN254.NewFP2copy(k[cw0])
+	one.Add(BN254.NewFP2int(1))
+	even := gtFromCoeffs([6]*BN254.FP2{zero, k[cw2], k[cw4], zero, zero, one})
+	odd := gtFromCoeffs([6]*BN254.FP2{k[cw5], zero, zero, k[cw1], k[cw3], zero})
+
+	// c = (1 + A) * sigma / (B * sigma)
+	odd.Inverse()
+	even.Mul(odd)
+	even.Mul(gtFromCoeffs([6]*BN254.FP2{zero, zero, zero, zero, BN254.NewFP2int(1), zero}))
+
+	c := gtCoeffs(even)
+	for j, pos := range []int{cw0, cw2, cw4} {
+		c[pos].ToBytes(buf[1+j*gtCoeffBytes : 1+(j+1)*gtCoeffBytes])
+	}
+	return buf
+}
+
+// decompress a GT element, the result still has to be checked for membership in GT
+func decompressGT(b []byte) (*BN254.FP12, error) {
+	if len(b) != gtBytes || b[0] > 1 {
+		return nil, errBadGT
+	}
+	if b[0] == 1 {
+		for _, x := range b[1:] {
+			if x != 0 {
+				return nil, errBadGT
+			}
+		}
+		return BN254.NewFP12int(1), nil
+	}
+
+	var c [3]*BN254.FP2
+	for j := range c {
+		c[j] = BN254.FP2_fromBytes(b[1+j*gtCoeffBytes : 1+(j+1)*gtCoeffBytes])
+	}
+
+	// m = (c + sigma) / (c - sigma)
+	zero := BN254.NewFP2int(0)
+	minusOne := BN254.NewFP2int(1)
+	minusOne.Neg()
+	num := gtFromCoeffs([6]*BN254.FP2{zero, c[1], c[2], zero, BN254.NewFP2int(1), c[0]})
+	den := gtFromCoeffs([6]*BN254.FP2{zero, c[1], c[2], zero, minusOne, c[0]})
+	den.Inverse()
+	num.Mul(den)
+	return num, nil
+}
+
+// the six FP2 coefficients of m in the order of FP12.ToBytes
+func gtCoeffs(m *BN254.FP12) [6]*BN254.FP2 {
+	var buf [6 * gtCoeffBytes]byte
+	m.ToBytes(buf[:])
+	var k [6]*BN254.FP2
+	for j := range k {
+		k[j] = BN254.FP2_fromBytes(buf[j*gtCoeffBytes : (j+1)*gtCoeffBytes])
+	}
+	return k
+}
+
+// inverse of gtCoeffs
+func gtFromCoeffs(k [6]*BN254.FP2) *BN254.FP12 {
+	return BN254.NewFP12fp4s(
+		BN254.NewFP4fp2s(k[cw0], k[cw3]),
+		BN254.NewFP4fp2s(k[cw1], k[cw4]),
+		BN254.NewFP4fp2s(k[cw2], k[cw5]))
+}
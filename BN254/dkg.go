@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/miracl/core/go/core/BN254"
+)
+
+// ----------- Distributed Setup
+// n parties jointly run Setup so that no single party learns alpha or the
+// exponents of h0, k0 and the h/k elements. Every party contributes random
+// group elements and a secret polynomial f_p of degree t-1; the public key is
+// the product of all contributions and alpha = sum f_p(0).
+//
+// Round 1: every party broadcasts a hash commitment to its contribution, so no
+//          party can choose its contribution after seeing the others.
+// Round 2: every party broadcasts its contribution and privately sends
+//          f_p(j) to party j, who checks it against the commitments in GT.
+// Finish:  every party checks the transcript and derives PK and its share
+//          MK_j = g1^(sum f_p(j)) of MK, usable with partialKeyGen.
+//
+// The polynomial commitments are e(g1,g2)^a_p,k in GT, and the product of the
+// constant terms is omega = e(g1,g2)^alpha. As omega is part of every BESTIE
+// public key, the commitments reveal nothing about alpha beyond what a
+// centralised setup publishes: neither g2^alpha nor MK = g1^alpha is ever
+// published, so the analysis of BESTIE applies unchanged.
+
+// ----------- Structs
+
+// public contribution of one party to the distributed setup
+type dkgContribution struct {
+	index      int
+	h0         *BN254.ECP
+	k0         *BN254.ECP
+	helements0 []*BN254.ECP
+	helements1 []*BN254.ECP
+	kelements0 []*BN254.ECP
+	kelements1 []*BN254.ECP
+	commits    []*BN254.FP12 // e(g1,g2)^a_k for the coefficients a_0 ... a_t-1 of the sharing polynomial
+}
+
+// everything broadcast during the distributed setup, in order of party index
+type dkgTranscript struct {
+	hashes        [][]byte
+	contributions []*dkgContribution
+}
+
+// state of one party during the distributed setup
+type dkgParty struct {
+	index   int
+	t       int
+	n       int
+	contrib *dkgContribution
+	gt      *BN254.FP12        // e(g1,g2), base of the commitments
+	coeffs  []*BN254.BIG       // secret sharing polynomial, wiped by finish
+	shares  map[int]*BN254.BIG // f_p(index) received from party p
+}
+
+var errDKGShare = errors.New("ERROR: share does not match the dealer's commitments")
+
+// Create party index = 1 ... n of a t-of-n distributed setup for ID length l
+func newDKGParty(index int, t int, n int, l int) (*dkgParty, error) {
+	if t < 1 || t > n {
+		return nil, errors.New("ERROR: threshold t must be between 1 and n")
+	}
+	if index < 1 || index > n {
+		return nil, fmt.Errorf("ERROR: party index %d not between 1 and %d", index, n)
+	}
+
+	q := BN254.NewBIGints(BN254.CURVE_Order)
+	g1 := BN254.ECP_generator()
+	g2 := BN254.ECP2_generator()
+
+	// h0, k0 and the h/k elements, generated like in Setup 4
+	h0Rand := BN254.Randomnum(q, rng)
+	h0 := g1mul(g1, h0Rand)
+	wipeBIG(h0Rand)
+	k0Rand := BN254.Randomnum(q, rng)
+	k0 := g1mul(g1, k0Rand)
+	wipeBIG(k0Rand)
+	elements, err := genElements(context.Background(), g1, 4, l, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	contrib := &dkgContribution{
+		index:      index,
+		h0:         h0,
+		k0:         k0,
+		helements0: elements[0],
+		helements1: elements[1],
+		kelements0: elements[2],
+		kelements1: elements[3],
+		commits:    make([]*BN254.FP12, t),
+	}
+
+	gt := fexp(ate(g2, g1))
+	coeffs := make([]*BN254.BIG, t)
+	for k := 0; k < t; k++ {
+		coeffs[k] = BN254.Randomnum(q, rng)
+		contrib.commits[k] = gtpow(gt, coeffs[k])
+	}
+
+	return &dkgParty{
+		index:   index,
+		t:       t,
+		n:       n,
+		contrib: contrib,
+		gt:      gt,
+		coeffs:  coeffs,
+		shares:  map[int]*BN254.BIG{},
+	}, nil
+}
+
+// Round 1: hash commitment to the contribution
+func (p *dkgParty) commitment() []byte {
+	return p.contrib.hash()
+}
+
+// Round 2: the contribution itself
+func (p *dkgParty) reveal() *dkgContribution {
+	return p.contrib
+}
+
+// Round 2: the share f_p(j) for party j, to be sent over a private channel
+func (p *dkgParty) shareFor(j int) *BN254.BIG {
+	q := BN254.NewBIGints(BN254.CURVE_Order)
+	x := BN254.NewBIGint(j)
+	f := BN254.NewBIGint(0)
+	for k := p.t - 1; k >= 0; k-- {
+		f = BN254.Modmul(f, x, q)
+		f = BN254.Modadd(f, p.coeffs[k], q)
+	}
+	return f
+}
+
+// Round 2: check the share sent by the dealer of contrib against its commitments
+// finish checks it again against the contribution in the transcript, as the
+// dealer may have sent commitments other than the ones it broadcast.
+func (p *dkgParty) receive(contrib *dkgContribution, share *BN254.BIG) error {
+	if err := p.checkShare(contrib, share); err != nil {
+		return err
+	}
+	p.shares[contrib.index] = share
+	return nil
+}
+
+// e(g1,g2)^share has to equal C_0 * C_1^j * ... * C_t-1^(j^t-1) for the commitments of contrib
+func (p *dkgParty) checkShare(contrib *dkgContribution, share *BN254.BIG) error {
+	if len(contrib.commits) != p.t {
+		return fmt.Errorf("ERROR: party %d committed to %d coefficients, expected %d", contrib.index, len(contrib.commits), p.t)
+	}
+
+	q := BN254.NewBIGints(BN254.CURVE_Order)
+	x := BN254.NewBIGint(p.index)
+	power := BN254.NewBIGint(1)
+	expected := BN254.NewFP12int(1)
+	for k := 0; k < p.t; k++ {
+		gtmul(expected, gtpow(contrib.commits[k], power))
+		power = BN254.Modmul(power, x, q)
+	}
+
+	if !gtpow(p.gt, share).Equals(expected) {
+		return fmt.Errorf("ERROR: party %d: %v", contrib.index, errDKGShare)
+	}
+	return nil
+}
+
+// Finish: verify the transcript and return PK and this party's share of MK
+// The secret polynomial and the received shares are wiped.
+func (p *dkgParty) finish(tr *dkgTranscript) (*pk, *mkShare, error) {
+	pubKey, err := verifyTranscript(tr, len(p.contrib.helements0), p.t, p.n)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(p.shares) != p.n {
+		return nil, nil, fmt.Errorf("ERROR: received %d of %d shares", len(p.shares), p.n)
+	}
+	// every share has to match the commitments that were broadcast, not only those sent along with it
+	for _, contrib := range tr.contributions {
+		share, ok := p.shares[contrib.index]
+		if !ok {
+			return nil, nil, fmt.Errorf("ERROR: no share from party %d", contrib.index)
+		}
+		if err := p.checkShare(contrib, share); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// x_j = sum over p of f_p(j), MK_j = g1^x_j
+	q := BN254.NewBIGints(BN254.CURVE_Order)
+	x := BN254.NewBIGint(0)
+	for _, share := range p.shares {
+		x = BN254.Modadd(x, share, q)
+		wipeBIG(share)
+	}
+	share := &mkShare{p.index, g1mul(pubKey.g1, x)}
+	wipeBIG(x)
+	for _, a := range p.coeffs {
+		wipeBIG(a)
+	}
+	return pubKey, share, nil
+}
+
+// Check a transcript and combine the contributions into PK
+// Anyone can run this, not only the parties of the setup.
+func verifyTranscript(tr *dkgTranscript, l int, t int, n int) (*pk, error) {
+	if len(tr.hashes) != n || len(tr.contributions) != n {
+		return nil, fmt.Errorf("ERROR: transcript has %d commitments and %d contributions, expected %d", len(tr.hashes), len(tr.contributions), n)
+	}
+
+	g1 := BN254.ECP_generator()
+	g2 := BN254.ECP2_generator()
+	h0 := BN254.NewECP()
+	k0 := BN254.NewECP()
+	helements0 := make([]*BN254.ECP, l)
+	helements1 := make([]*BN254.ECP, l)
+	kelements0 := make([]*BN254.ECP, l)
+	kelements1 := make([]*BN254.ECP, l)
+	for i := 0; i < l; i++ {
+		helements0[i] = BN254.NewECP()
+		helements1[i] = BN254.NewECP()
+		kelements0[i] = BN254.NewECP()
+		kelements1[i] = BN254.NewECP()
+	}
+	omega := BN254.NewFP12int(1) // product of e(g1,g2)^f_p(0) = e(g1,g2)^alpha
+
+	for j, contrib := range tr.contributions {
+		if contrib.index != j+1 {
+			return nil, fmt.Errorf("ERROR: contribution %d comes from party %d", j+1, contrib.index)
+		}
+		if err := contrib.check(l, t); err != nil {
+			return nil, fmt.Errorf("ERROR: party %d: %v", contrib.index, err)
+		}
+		if !bytes.Equal(contrib.hash(), tr.hashes[j]) {
+			return nil, fmt.Errorf("ERROR: party %d revealed a different contribution than it committed to", contrib.index)
+		}
+
+		g1add(h0, contrib.h0)
+		g1add(k0, contrib.k0)
+		for i := 0; i < l; i++ {
+			g1add(helements0[i], contrib.helements0[i])
+			g1add(helements1[i], contrib.helements1[i])
+			g1add(kelements0[i], contrib.kelements0[i])
+			g1add(kelements1[i], contrib.kelements1[i])
+		}
+		gtmul(omega, contrib.commits[0])
+	}
+
+	pubKey := &pk{BN254.NewBIGints(BN254.Modulus), g1, g2, h0, k0, helements0, helements1, kelements0, kelements1, omega}
+	if err := pubKey.Validate(); err != nil {
+		return nil, err
+	}
+	return pubKey, nil
+}
+
+// check the sizes of a contribution and that all its elements are valid group elements
+func (contrib *dkgContribution) check(l int, t int) error {
+	if len(contrib.helements0) != l || len(contrib.helements1) != l ||
+		len(contrib.kelements0) != l || len(contrib.kelements1) != l {
+		return errLength
+	}
+	if len(contrib.commits) != t {
+		return fmt.Errorf("ERROR: %d polynomial commitments, expected %d", len(contrib.commits), t)
+	}
+	if err := checkG1("h0", -1, contrib.h0); err != nil {
+		return err
+	}
+	if err := checkG1("k0", -1, contrib.k0); err != nil {
+		return err
+	}
+	for i := 0; i < l; i++ {
+		for _, e := range []struct {
+			name string
+			P    *BN254.ECP
+		}{
+			{"h_i,0", contrib.helements0[i]},
+			{"h_i,1", contrib.helements1[i]},
+			{"k_i,0", contrib.kelements0[i]},
+			{"k_i,1", contrib.kelements1[i]},
+		} {
+			if err := checkG1(e.name, i, e.P); err != nil {
+				return err
+			}
+		}
+	}
+	for k := 0; k < t; k++ {
+		if err := checkGT(fmt.Sprintf("C_%d", k), contrib.commits[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SHA-256 over the serialised contribution, including the party index
+func (contrib *dkgContribution) hash() []byte {
+	l := len(contrib.helements0)
+	buf := make([]byte, 0, 8+(2+4*l)*g1Bytes+len(contrib.commits)*gtBytes)
+	buf = appendLen(buf, contrib.index)
+	buf = appendLen(buf, l)
+	buf = appendG1(buf, contrib.h0)
+	buf = appendG1(buf, contrib.k0)
+	for _, elements := range [][]*BN254.ECP{contrib.helements0, contrib.helements1, contrib.kelements0, contrib.kelements1} {
+		for i := 0; i < l; i++ {
+			buf = appendG1(buf, elements[i])
+		}
+	}
+	for _, C := range contrib.commits {
+		buf = appendGT(buf, C)
+	}
+	h := sha256.Sum256(buf)
+	return h[:]
+}
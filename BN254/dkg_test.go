@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestDKG(t *testing.T) {
+	id := "01101010"
+	s := &subset{cl: "*1****10", rl: "*****110"}
+
+	pubKey, shares, err := runDKG(len(id), 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := []*partialKey{partialKeyGen(id, shares[0], pubKey), partialKeyGen(id, shares[2], pubKey)}
+	secKey, err := combineKeys(parts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyKey(pubKey, id, secKey); err != nil {
+		t.Fatal(err)
+	}
+	message := createRandomM(pubKey)
+	mes, err := decrypt(s, id, secKey, encrypt(s, pubKey, message))
+	if err != nil || !mes.Equals(message) {
+		t.Errorf("key of the distributed setup does not decrypt: %v", err)
+	}
+}
+
+func TestDKGWrongShare(t *testing.T) {
+	party, _ := newDKGParty(1, 2, 3, 4)
+	dealer, _ := newDKGParty(2, 2, 3, 4)
+
+	if err := party.receive(dealer.reveal(), dealer.shareFor(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := party.receive(dealer.reveal(), dealer.shareFor(3)); err == nil {
+		t.Error("share for another party was accepted")
+	}
+}
+
+func TestDKGEquivocatingDealer(t *testing.T) {
+	party, _ := newDKGParty(1, 2, 2, 4)
+	dealer, _ := newDKGParty(2, 2, 2, 4)
+	private, _ := newDKGParty(2, 2, 2, 4) // commitments the dealer only shows to party 1
+
+	tr := &dkgTranscript{}
+	for _, p := range []*dkgParty{party, dealer} {
+		tr.hashes = append(tr.hashes, p.commitment())
+		tr.contributions = append(tr.contributions, p.reveal())
+	}
+	if err := party.receive(party.reveal(), party.shareFor(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := party.receive(private.reveal(), private.shareFor(1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := party.finish(tr); err == nil {
+		t.Error("share matching private commitments only was accepted")
+	}
+}
+
+func TestDKGTranscript(t *testing.T) {
+	parties := make([]*dkgParty, 2)
+	tr := &dkgTranscript{}
+	for j := range parties {
+		parties[j], _ = newDKGParty(j+1, 1, 2, 4)
+		tr.hashes = append(tr.hashes, parties[j].commitment())
+		tr.contributions = append(tr.contributions, parties[j].reveal())
+	}
+	if _, err := verifyTranscript(tr, 4, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// party 2 swaps its commitment after seeing the one of party 1
+	tr.contributions[1].commits[0] = tr.contributions[0].commits[0]
+	if _, err := verifyTranscript(tr, 4, 1, 2); err == nil {
+		t.Error("contribution differing from its hash commitment was accepted")
+	}
+}
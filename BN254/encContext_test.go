@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/miracl/core/go/core/BN254"
+)
+
+func TestEncContextDecrypts(t *testing.T) {
+	l := 8
+	pubKey, mk := setup(l)
+	ec := newEncContext(pubKey, 2)
+
+	for i := 0; i < 3; i++ {
+		id, s := genSubset(l, 0.5)
+		secKey := keyGen(id, mk, pubKey)
+		// the second header comes from the cached tables
+		for j := 0; j < 2; j++ {
+			message := randomGT(pubKey)
+			mes, err := decrypt(s, id, secKey, ec.encrypt(s, message))
+			if err != nil || !mes.Equals(message) {
+				t.Errorf("subset %d, header %d: %v", i, j, err)
+			}
+		}
+	}
+}
+
+func TestEncContextCache(t *testing.T) {
+	l := 4
+	pubKey, _ := setup(l)
+	ec := newEncContext(pubKey, 2)
+	s1 := &subset{"0***", "1***"}
+	s2 := &subset{"1***", "0***"}
+	s3 := &subset{"****", "11**"}
+
+	agg1 := ec.aggregates(s1)
+	ec.aggregates(s2)
+	if ec.aggregates(s1) != agg1 {
+		t.Error("s1 was not cached")
+	}
+	// s2 is the least recently used subset now
+	ec.aggregates(s3)
+	if ec.cache.len() != 2 {
+		t.Errorf("cache holds %d subsets, want 2", ec.cache.len())
+	}
+	if _, ok := ec.cache.get(s2); ok {
+		t.Error("s2 was not evicted")
+	}
+	if _, ok := ec.cache.get(s1); !ok {
+		t.Error("s1 was evicted")
+	}
+
+	ec.invalidate(s1)
+	if _, ok := ec.cache.get(s1); ok {
+		t.Error("s1 is still cached after invalidate")
+	}
+	if ec.aggregates(s1) == agg1 {
+		t.Error("s1 was not recomputed after invalidate")
+	}
+	ec.invalidateAll()
+	if ec.cache.len() != 0 {
+		t.Errorf("cache holds %d subsets after invalidateAll", ec.cache.len())
+	}
+}
+
+func TestG1TableMul(t *testing.T) {
+	q := BN254.NewBIGints(BN254.CURVE_Order)
+	P := g1mul(BN254.ECP_generator(), BN254.Randomnum(q, rng))
+	tb := newG1Table(P)
+
+	qMinus1 := BN254.Modadd(q, BN254.Modneg(BN254.NewBIGint(1), q), q)
+	exponents := []*BN254.BIG{BN254.NewBIGint(0), BN254.NewBIGint(1), BN254.NewBIGint(16), qMinus1}
+	for i := 0; i < 5; i++ {
+		exponents = append(exponents, BN254.Randomnum(q, rng))
+	}
+	for _, e := range exponents {
+		if !tb.mul(e).Equals(g1mul(P, e)) {
+			t.Errorf("table gives a different P^%s", e.ToString())
+		}
+	}
+}
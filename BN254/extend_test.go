@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestExtendUpgradedKey(t *testing.T) {
+	oldID, newID := "01101010", "011010101100"
+	pubKey, mk := setup(len(oldID))
+	oldKey := keyGen(oldID, mk, pubKey)
+
+	extended, ext, err := extendPK(pubKey, len(newID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := extended.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	up, err := ext.upgrade(extended, oldKey.y0, oldKey.z)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := newUpgradedKey(oldKey)
+	if err := key.apply(up); err != nil {
+		t.Fatal(err)
+	}
+	if err := key.apply(up); err == nil {
+		t.Error("upgrade was applied twice")
+	}
+
+	// wildcards at the new positions: both the upgraded and a new key decrypt
+	s := &subset{cl: "*1****10****", rl: "*****110****"}
+	cipher, message := encapsulate(s, extended)
+	mes, err := decryptUpgraded(s, oldID, key, cipher)
+	if err != nil || !mes.Equals(message) {
+		t.Errorf("upgraded key does not decrypt a wildcard-extended header: %v", err)
+	}
+	mes, err = decrypt(s, newID, keyGen(newID, mk, extended), cipher)
+	if err != nil || !mes.Equals(message) {
+		t.Errorf("new key does not decrypt: %v", err)
+	}
+
+	// a fixed bit at a new position in CL or RL
+	for _, fixed := range []*subset{{cl: "*1****10***1", rl: s.rl}, {cl: s.cl, rl: "*****1101***"}} {
+		cipher, _ := encapsulate(fixed, extended)
+		if _, err := decryptUpgraded(fixed, oldID, key, cipher); err != errNotWildcard {
+			t.Errorf("CL %s, RL %s: got %v, want errNotWildcard", fixed.cl, fixed.rl, err)
+		}
+	}
+
+	// the upgrade only fits a matching y0 and z
+	other := keyGen("11111111", mk, pubKey)
+	if _, err := ext.upgrade(extended, oldKey.y0, other.z); err == nil {
+		t.Error("upgrade for mismatched y0 and z was issued")
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRerandomizeKey(t *testing.T) {
+	l := 8
+	pubKey, mk := setup(l)
+	id, s := genSubset(l, 0.5)
+	secKey := keyGen(id, mk, pubKey)
+	before := secKey.toBytes()
+
+	newKey, err := rerandomizeKey(pubKey, id, secKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(secKey.toBytes(), before) {
+		t.Error("rerandomizeKey changed the original key")
+	}
+	if newKey.x0.Equals(secKey.x0) || newKey.y0.Equals(secKey.y0) || newKey.z.Equals(secKey.z) ||
+		newKey.yOdd[0].Equals(secKey.yOdd[0]) {
+		t.Error("re-randomized key shares components with the original")
+	}
+	if err := verifyKey(pubKey, id, newKey); err != nil {
+		t.Fatalf("re-randomized key does not verify: %v", err)
+	}
+
+	cipher, message := encapsulate(s, pubKey)
+	mes, err := decrypt(s, id, newKey, cipher)
+	if err != nil || !mes.Equals(message) {
+		t.Errorf("re-randomized key does not decrypt: %v", err)
+	}
+
+	if _, err := rerandomizeKey(pubKey, id[1:], secKey); err == nil {
+		t.Error("ID of the wrong length was accepted")
+	}
+	short := *secKey
+	short.yOdd = short.yOdd[1:]
+	if _, err := rerandomizeKey(pubKey, id, &short); err != errLength {
+		t.Errorf("key of the wrong length: got %v, want errLength", err)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSeededPKRebuild(t *testing.T) {
+	l := 8
+	spk, mk, err := setupSeeded(l, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rebuilt, err := seededPKFromBytes(spk.toBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rebuilt.pubKey.toBytes(), spk.pubKey.toBytes()) {
+		t.Fatal("rebuilt public key differs")
+	}
+
+	id, s := genSubset(l, 0.5)
+	secKey := keyGen(id, mk, spk.pubKey)
+	cipher, message := encapsulate(s, rebuilt.pubKey)
+	mes, err := decrypt(s, id, secKey, cipher)
+	if err != nil || !mes.Equals(message) {
+		t.Errorf("header for the rebuilt public key does not decrypt: %v", err)
+	}
+
+	// extendPK only needs k0, which is seeded as well
+	extended, ext, err := extendPK(spk.pubKey, l+2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ext.Destroy()
+	id, s = genSubset(l+2, 0.5)
+	cipher, message = encapsulate(s, extended)
+	mes, err = decrypt(s, id, keyGen(id, mk, extended), cipher)
+	if err != nil || !mes.Equals(message) {
+		t.Errorf("header for the extended seeded public key does not decrypt: %v", err)
+	}
+}
+
+func TestSeededPKRejects(t *testing.T) {
+	spk, _, err := setupSeeded(4, []byte("seed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := spk.toBytes()
+
+	if _, err := seededPKFromBytes(raw[:len(raw)-1]); err == nil {
+		t.Error("truncated seeded public key accepted")
+	}
+	empty := appendLen(appendLen(nil, 4), 0)
+	if _, err := seededPKFromBytes(append(empty, raw[12:]...)); err == nil {
+		t.Error("empty seed accepted")
+	}
+	long := append(appendLen(nil, maxSeededL+1), raw[4:]...)
+	if _, err := seededPKFromBytes(long); err == nil {
+		t.Error("ID length above maxSeededL accepted")
+	}
+}
+
+func TestHashToG1SeedPrefix(t *testing.T) {
+	if hashToG1([]byte("seedh"), "1,0").Equals(hashToG1([]byte("seed"), "h1,0")) {
+		t.Error("seed and label are not separated")
+	}
+	if !hashToG1([]byte("seed"), "h1,0").Equals(hashToG1([]byte("seed"), "h1,0")) {
+		t.Error("hashToG1 is not deterministic")
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// ----------- Streaming AEAD
+// The payload of a ciphertext file is sealed in chunks of chunkBytes, so
+// encrypt and decrypt never hold more than one chunk in memory. Chunk i is
+// sealed with the nonce prefix || i || last, where prefix is random per file,
+// i is a 4 byte big endian counter and last is 1 for the final chunk and 0
+// otherwise. Reordered, dropped or appended chunks and a file cut off at a
+// chunk boundary all fail to open.
+
+// plaintext bytes per chunk
+const chunkBytes = 64 << 10
+
+// random part of the chunk nonces, the rest are the counter and the last flag
+const noncePrefixBytes = nonceBytes - 5
+
+var errCorrupted = errors.New("ERROR: payload is corrupted or truncated")
+
+// nonce of chunk counter
+func chunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, nonceBytes)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixBytes:], counter)
+	if last {
+		nonce[nonceBytes-1] = 1
+	}
+	return nonce
+}
+
+// Seal everything read from in chunk by chunk and write the sealed chunks to out
+// ad is authenticated with every chunk.
+func sealStream(aead cipher.AEAD, prefix []byte, ad []byte, in io.Reader, out io.Writer) error {
+	br := bufio.NewReader(in)
+	plain := make([]byte, chunkBytes)
+	defer wipeBytes(plain)
+	sealed := make([]byte, 0, chunkBytes+aead.Overhead())
+
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(br, plain)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		last, err := isLast(br, n, chunkBytes)
+		if err != nil {
+			return err
+		}
+
+		sealed = aead.Seal(sealed[:0], chunkNonce(prefix, counter, last), plain[:n], ad)
+		if _, err := out.Write(sealed); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		if counter == math.MaxUint32 {
+			return errors.New("ERROR: input too long")
+		}
+	}
+}
+
+// Open the sealed chunks read from in and write the plaintext to out
+// Every chunk is written as soon as it is authenticated, so if an error is
+// returned the output written so far has to be discarded.
+func openStream(aead cipher.AEAD, prefix []byte, ad []byte, in io.Reader, out io.Writer) error {
+	br := bufio.NewReader(in)
+	sealed := make([]byte, chunkBytes+aead.Overhead())
+	plain := make([]byte, 0, chunkBytes)
+	defer func() { wipeBytes(plain[:cap(plain)]) }()
+
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(br, sealed)
+		if err == io.EOF {
+			return errCorrupted // no final chunk
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		last, err := isLast(br, n, len(sealed))
+		if err != nil {
+			return err
+		}
+
+		plain, err = aead.Open(plain[:0], chunkNonce(prefix, counter, last), sealed[:n], ad)
+		if err != nil {
+			return errCorrupted
+		}
+		if _, err := out.Write(plain); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		if counter == math.MaxUint32 {
+			return errCorrupted
+		}
+	}
+}
+
+// a chunk of n bytes is the last one if it is short or nothing follows it
+func isLast(br *bufio.Reader, n int, size int) (bool, error) {
+	if n < size {
+		return true, nil
+	}
+	if _, err := br.Peek(1); err != nil {
+		if err == io.EOF {
+			return true, nil
+		}
+		return false, err
+	}
+	return false, nil
+}
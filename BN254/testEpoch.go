@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// func main() {
+
+// 	// Initialise Random number generator
+// 	initRNG()
+
+// 	s := &subset{cl: "*1****10", rl: "*****110"}
+
+// 	testEpochs([]string{"01101010", "11101110"}, s)
+// }
+
+// Function to rotate to a new epoch, re-key the devices with ids and retire the old epoch
+func testEpochs(ids []string, s *subset) {
+
+	fmt.Println("\n")
+	fmt.Println("-------  Epochs  ---------")
+
+	m := newEpochManager(len(ids[0]))
+	old := m.current()
+	keys := make([]*sk, len(ids))
+	mk := old.mk.get()
+	for j, id := range ids {
+		keys[j] = keyGen(id, mk, old.pubKey)
+	}
+	wipeECP(mk)
+
+	next := m.rotate()
+	envelopes, err := m.rekey(old.number, next.number, ids)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// during migration both epochs are active
+	ciphers, message := m.encrypt(s)
+	fmt.Println("Active epochs: ", len(ciphers))
+
+	for j, id := range ids {
+		newKey, err := openEnvelope(envelopes[j], keys[j], next.pubKey)
+		if err != nil {
+			fmt.Println("ID", id, ":", err)
+			continue
+		}
+		oldMes, oldErr := decrypt(s, id, keys[j], ciphers[0].cipher)
+		newMes, newErr := decrypt(s, id, newKey, ciphers[1].cipher)
+		fmt.Println("ID", id, "re-keyed. Old epoch decrypts: ", oldErr == nil && message.Equals(oldMes),
+			" New epoch decrypts: ", newErr == nil && message.Equals(newMes))
+	}
+
+	// a device can not open the envelope of another one
+	if len(ids) > 1 {
+		_, err := openEnvelope(envelopes[1], keys[0], next.pubKey)
+		fmt.Println("Foreign envelope rejected: ", err != nil)
+	}
+
+	now := time.Now()
+	m.scheduleRetire(old.number, now.Add(time.Hour))
+	fmt.Println("Retired before schedule: ", m.retireDue(now))
+	fmt.Println("Retired after schedule: ", m.retireDue(now.Add(time.Hour)))
+	ciphers, _ = m.encrypt(s)
+	fmt.Println("Active epochs: ", len(ciphers))
+}
@@ -50,6 +50,7 @@ package main
 // outputMessage, err := decrypt(s, id, secKey, cipher)
 // if err != nil {
 // 	fmt.Println("Error: ", err)
+// 	fmt.Println(explain(id, s))
 // } else {
 // 	equalMes := inputMessage.Equals(outputMessage) // test if encrypted message is same as decrypted message
 // 	if equalMes {
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+)
+
+// func main() {
+
+// 	// Initialise Random number generator
+// 	initRNG()
+
+// 	testSeededSetup("01101010", &subset{cl: "*1****10", rl: "*****110"})
+// }
+
+// Function to compare the size of a seeded public key with a full one and
+// decrypt a header that was encrypted with the rebuilt public key
+func testSeededSetup(id string, s *subset) {
+
+	fmt.Println("\n")
+	fmt.Println("-------  Seeded Setup  ---------")
+
+	spk, mk, err := setupSeeded(len(id), nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	raw := spk.toBytes()
+	fmt.Println("Seeded public key: ", len(raw), " bytes, full public key: ", len(spk.pubKey.toBytes()), " bytes")
+
+	// a broadcaster only gets raw
+	rebuilt, err := seededPKFromBytes(raw)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	message := createRandomM(rebuilt.pubKey)
+	cipher := encrypt(s, rebuilt.pubKey, message)
+
+	secKey := keyGen(id, mk, spk.pubKey)
+	mes, err := decrypt(s, id, secKey, cipher)
+	fmt.Println("Is the rebuilt public key the same? ", string(rebuilt.pubKey.toBytes()) == string(spk.pubKey.toBytes()))
+	fmt.Println("Message decrypted: ", err == nil && message.Equals(mes))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+)
+
+// func main() {
+
+// 	// Initialise Random number generator
+// 	initRNG()
+
+// 	id := "01101010"
+// 	s := &subset{cl: "*1****10", rl: "*****110"}
+
+// 	testThreshold(id, s, 3, 5)
+// }
+
+// Function to simulate t-of-n key authorities issuing a key for id
+// once with t authorities, which has to work, and once with t-1, which must not
+func testThreshold(id string, s *subset, t int, n int) {
+
+	fmt.Println("\n")
+	fmt.Println("-------  Threshold KeyGen  ---------")
+	fmt.Println("Authorities: ", n, " Threshold: ", t)
+
+	pubKey, shares, err := setupThreshold(len(id), t, n)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// every authority issues its partial key
+	parts := make([]*partialKey, n)
+	for j := 0; j < n; j++ {
+		parts[j] = partialKeyGen(id, shares[j], pubKey)
+	}
+
+	// the device combines the last t of them
+	for _, k := range []int{t, t - 1} {
+		secKey, err := combineKeys(parts[n-k:])
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		message := createRandomM(pubKey)
+		cipher := encrypt(s, pubKey, message)
+		mes, err := decrypt(s, id, secKey, cipher)
+
+		fmt.Println("Combined", k, "partial keys. Key verifies: ", verifyKey(pubKey, id, secKey) == nil,
+			" Message decrypted: ", err == nil && message.Equals(mes))
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/miracl/core/go/core/BN254"
+)
+
+// ----------- Threshold Master Key
+// MK is shared among n key authorities with Shamir's scheme in the exponent:
+// authority j holds MK_j = F(j) = MK * g1^(a1*j + ... + a(t-1)*j^(t-1)), and any
+// t of them together can issue keys while fewer learn nothing about MK.
+// Each authority runs a normal KeyGen with MK_j, and the device combines
+// t of these partial keys into a normal SK_ID, see combineKeys.
+// omega_j = e(MK_j, g2) is published with PK, so a partial key can be checked
+// against the authority it claims to come from, see verifyPartial.
+
+// ----------- Structs
+
+// share of the master key held by authority index = 1 ... n
+type mkShare struct {
+	index int
+	mk    *BN254.ECP
+}
+
+// partial secret key for an ID issued by authority index
+type partialKey struct {
+	index  int
+	secKey *sk
+}
+
+// Threshold Setup (l, t, n) -> PK, MK_1 ... MK_n
+// MK itself only exists until it is split and is wiped afterwards
+func setupThreshold(l int, t int, n int) (pubKey *pk, shares []*mkShare, err error) {
+	pubKey, mk := setup(l)
+	defer wipeECP(mk)
+
+	shares, err = shareMK(mk, t, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pubKey, shares, nil
+}
+
+// Split MK into n shares, any t of which can issue keys
+func shareMK(mk *BN254.ECP, t int, n int) ([]*mkShare, error) {
+	if t < 1 || t > n {
+		return nil, errors.New("ERROR: threshold t must be between 1 and n")
+	}
+
+	q := BN254.NewBIGints(BN254.CURVE_Order)
+	g1 := BN254.ECP_generator()
+
+	// random coefficients a1 ... a(t-1), a0 is the exponent of MK which nobody needs to know
+	coeffs := make([]*BN254.BIG, t-1)
+	for k := 0; k < t-1; k++ {
+		coeffs[k] = BN254.Randomnum(q, rng)
+	}
+
+	shares := make([]*mkShare, n)
+	for j := 1; j <= n; j++ {
+		// f(j) = a1*j + ... + a(t-1)*j^(t-1) with Horner's method
+		x := BN254.NewBIGint(j)
+		f := BN254.NewBIGint(0)
+		for k := t - 2; k >= 0; k-- {
+			f = BN254.Modadd(f, coeffs[k], q)
+			f = BN254.Modmul(f, x, q)
+		}
+
+		// MK_j = MK * g1^f(j)
+		share := g1mul(g1, f)
+		g1add(share, mk)
+		shares[j-1] = &mkShare{j, share}
+		wipeBIG(f)
+	}
+
+	for k := 0; k < t-1; k++ {
+		wipeBIG(coeffs[k])
+	}
+	return shares, nil
+}
+
+// KeyGen of a single authority (ID, MK_j, PK) -> partial SK_ID
+func partialKeyGen(id string, share *mkShare, pubKey *pk) *partialKey {
+	return &partialKey{share.index, keyGen(id, share.mk, pubKey)}
+}
+
+// public verification value omega_j = e(MK_j, g2) of an authority
+func (share *mkShare) verifier(pubKey *pk) *BN254.FP12 {
+	return fexp(ate(pubKey.g2, share.mk))
+}
+
+// Verify a partial key against the verification value omegaJ of the authority it claims to come from
+// A partial key is a normal key for MK_j, so verifyKey applies with omega_j in place of omega.
+func verifyPartial(pubKey *pk, omegaJ *BN254.FP12, id string, part *partialKey) error {
+	pubKeyJ := *pubKey
+	pubKeyJ.omega = omegaJ
+	return verifyKey(&pubKeyJ, id, part.secKey)
+}
+
+// Combine partial keys of t different authorities into SK_ID
+// Every component of a partial key is linear in MK_j and in its r_j and alphaOmega_j,
+// so with the Lagrange coefficients lambda_j the product of part_j^lambda_j is a normal
+// key for MK with r = sum lambda_j*r_j and alphaOmega = sum lambda_j*alphaOmega_j.
+// With fewer than t parts the result is a valid looking key that does not decrypt.
+func combineKeys(parts []*partialKey) (*sk, error) {
+	if len(parts) == 0 {
+		return nil, errors.New("ERROR: no partial keys to combine")
+	}
+
+	l := len(parts[0].secKey.xelements)
+	indices := make([]int, len(parts))
+	for j, part := range parts {
+		if err := part.secKey.Validate(); err != nil {
+			return nil, err
+		}
+		if len(part.secKey.xelements) != l {
+			return nil, errLength
+		}
+		if part.index < 1 {
+			return nil, fmt.Errorf("ERROR: partial key has authority index %d, indices start at 1", part.index)
+		}
+		for _, index := range indices[:j] {
+			if index == part.index {
+				return nil, errors.New("ERROR: partial keys must come from different authorities")
+			}
+		}
+		indices[j] = part.index
+	}
+	lambdas := lagrange(indices)
+
+	secKey := &sk{
+		x0:        BN254.NewECP(),
+		xelements: make([]*BN254.ECP, l),
+		y0:        BN254.NewECP(),
+		yEven:     make([]*BN254.ECP, l),
+		yOdd:      make([]*BN254.ECP, l),
+		z:         BN254.NewECP2(),
+	}
+	for i := 0; i < l; i++ {
+		secKey.xelements[i] = BN254.NewECP()
+		secKey.yEven[i] = BN254.NewECP()
+		secKey.yOdd[i] = BN254.NewECP()
+	}
+
+	for j, part := range parts {
+		lambda := lambdas[j]
+		g1add(secKey.x0, g1mul(part.secKey.x0, lambda))
+		g1add(secKey.y0, g1mul(part.secKey.y0, lambda))
+		for i := 0; i < l; i++ {
+			g1add(secKey.xelements[i], g1mul(part.secKey.xelements[i], lambda))
+			g1add(secKey.yEven[i], g1mul(part.secKey.yEven[i], lambda))
+			g1add(secKey.yOdd[i], g1mul(part.secKey.yOdd[i], lambda))
+		}
+		g2add(secKey.z, g2mul(part.secKey.z, lambda))
+	}
+	return secKey, nil
+}
+
+// Lagrange coefficients for interpolating at 0 from the given indices
+// lambda_j = product over m != j of m / (m - j) mod q
+func lagrange(indices []int) []*BN254.BIG {
+	q := BN254.NewBIGints(BN254.CURVE_Order)
+	lambdas := make([]*BN254.BIG, len(indices))
+	for j, xj := range indices {
+		num := BN254.NewBIGint(1)
+		den := BN254.NewBIGint(1)
+		for m, xm := range indices {
+			if m == j {
+				continue
+			}
+			num = BN254.Modmul(num, BN254.NewBIGint(xm), q)
+			diff := BN254.Modadd(BN254.NewBIGint(xm), BN254.Modneg(BN254.NewBIGint(xj), q), q)
+			den = BN254.Modmul(den, diff, q)
+		}
+		den.Invmodp(q)
+		lambdas[j] = BN254.Modmul(num, den, q)
+	}
+	return lambdas
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/miracl/core/go/core/BN254"
+)
+
+func TestThresholdKeys(t *testing.T) {
+	id := "01101010"
+	s := &subset{cl: "*1****10", rl: "*****110"}
+	pubKey, shares, err := setupThreshold(len(id), 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make([]*partialKey, len(shares))
+	for j, share := range shares {
+		parts[j] = partialKeyGen(id, share, pubKey)
+	}
+	cipher, message := encapsulate(s, pubKey)
+
+	// any t authorities
+	for _, chosen := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}} {
+		secKey, err := combineKeys([]*partialKey{parts[chosen[0]], parts[chosen[1]], parts[chosen[2]]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := verifyKey(pubKey, id, secKey); err != nil {
+			t.Errorf("authorities %v: %v", chosen, err)
+		}
+		mes, err := decrypt(s, id, secKey, cipher)
+		if err != nil || !mes.Equals(message) {
+			t.Errorf("authorities %v: key does not decrypt: %v", chosen, err)
+		}
+	}
+
+	// t-1 authorities give a key that neither verifies nor decrypts
+	secKey, err := combineKeys(parts[3:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyKey(pubKey, id, secKey); err == nil {
+		t.Error("key of t-1 authorities verifies")
+	}
+	if mes, err := decrypt(s, id, secKey, cipher); err == nil && mes.Equals(message) {
+		t.Error("key of t-1 authorities decrypts")
+	}
+}
+
+func TestThresholdIndices(t *testing.T) {
+	id := "0110"
+	pubKey, shares, err := setupThreshold(len(id), 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part := partialKeyGen(id, shares[0], pubKey)
+
+	if _, err := combineKeys([]*partialKey{part, part}); err == nil {
+		t.Error("duplicate authority accepted")
+	}
+	zero := &partialKey{0, partialKeyGen(id, shares[1], pubKey).secKey}
+	if _, err := combineKeys([]*partialKey{part, zero}); err == nil {
+		t.Error("authority index 0 accepted")
+	}
+	if _, err := combineKeys(nil); err == nil {
+		t.Error("empty list of partial keys accepted")
+	}
+	if _, err := shareMK(pubKey.g1, 4, 3); err == nil {
+		t.Error("threshold above n accepted")
+	}
+}
+
+func TestThresholdWrongParty(t *testing.T) {
+	id := "0110"
+	pubKey, shares, err := setupThreshold(len(id), 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifiers := make([]*BN254.FP12, len(shares))
+	for j, share := range shares {
+		verifiers[j] = share.verifier(pubKey)
+		if err := verifyPartial(pubKey, verifiers[j], id, partialKeyGen(id, share, pubKey)); err != nil {
+			t.Errorf("partial key of authority %d: %v", share.index, err)
+		}
+	}
+
+	// authority 3 answers in place of authority 2
+	wrong := &partialKey{2, partialKeyGen(id, shares[2], pubKey).secKey}
+	if err := verifyPartial(pubKey, verifiers[1], id, wrong); err == nil {
+		t.Error("partial key of another authority verifies")
+	}
+	secKey, err := combineKeys([]*partialKey{partialKeyGen(id, shares[0], pubKey), wrong})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyKey(pubKey, id, secKey); err == nil {
+		t.Error("key combined with a partial key of another authority verifies")
+	}
+}
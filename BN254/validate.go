@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/miracl/core/go/core/BN254"
+)
+
+// ----------- Validation
+// Everything arriving from outside has to be checked before it is used:
+// all points must be members of their group and must not be the identity,
+// and all slices must have the same length l.
+
+// check every element of the public key
+func (pubKey *pk) Validate() error {
+	l := len(pubKey.helements0)
+	if len(pubKey.helements1) != l || len(pubKey.kelements0) != l || len(pubKey.kelements1) != l {
+		return fmt.Errorf("ERROR: public key elements have different lengths")
+	}
+	if err := checkG1("g1", -1, pubKey.g1); err != nil {
+		return err
+	}
+	if err := checkG2("g2", -1, pubKey.g2); err != nil {
+		return err
+	}
+	if err := checkG1("h0", -1, pubKey.h0); err != nil {
+		return err
+	}
+	if err := checkG1("k0", -1, pubKey.k0); err != nil {
+		return err
+	}
+	for i := 0; i < l; i++ {
+		if err := checkG1("h_i,0", i, pubKey.helements0[i]); err != nil {
+			return err
+		}
+		if err := checkG1("h_i,1", i, pubKey.helements1[i]); err != nil {
+			return err
+		}
+		if err := checkG1("k_i,0", i, pubKey.kelements0[i]); err != nil {
+			return err
+		}
+		if err := checkG1("k_i,1", i, pubKey.kelements1[i]); err != nil {
+			return err
+		}
+	}
+	return checkGT("omega", pubKey.omega)
+}
+
+// check every element of the secret key
+func (secKey *sk) Validate() error {
+	l := len(secKey.xelements)
+	if len(secKey.yEven) != l || len(secKey.yOdd) != l {
+		return fmt.Errorf("ERROR: secret key elements have different lengths")
+	}
+	if err := checkG1("x0", -1, secKey.x0); err != nil {
+		return err
+	}
+	if err := checkG1("y0", -1, secKey.y0); err != nil {
+		return err
+	}
+	for i := 0; i < l; i++ {
+		if err := checkG1("x_i", i, secKey.xelements[i]); err != nil {
+			return err
+		}
+		if err := checkG1("y_2i", i, secKey.yEven[i]); err != nil {
+			return err
+		}
+		if err := checkG1("y_2i-1", i, secKey.yOdd[i]); err != nil {
+			return err
+		}
+	}
+	return checkG2("z", -1, secKey.z)
+}
+
+// check every element of a q-ary secret key
+func (secKey *skQ) Validate() error {
+	l := len(secKey.xelements)
+	if len(secKey.yelements) != l {
+		return fmt.Errorf("ERROR: secret key elements have different lengths")
+	}
+	if err := checkG1("x0", -1, secKey.x0); err != nil {
+		return err
+	}
+	if err := checkG1("y0", -1, secKey.y0); err != nil {
+		return err
+	}
+	for i := 0; i < l; i++ {
+		if err := checkG1("x_i", i, secKey.xelements[i]); err != nil {
+			return err
+		}
+		if k := len(secKey.yelements[i]); k < 2 || k != len(secKey.yelements[0]) {
+			return fmt.Errorf("ERROR: secret key has %d symbols at position %d", k, i+1)
+		}
+		for c := range secKey.yelements[i] {
+			if err := checkG1(fmt.Sprintf("y_i,%d", c), i, secKey.yelements[i][c]); err != nil {
+				return err
+			}
+		}
+	}
+	return checkG2("z", -1, secKey.z)
+}
+
+// check every element of the header
+func (cipher *hdr) Validate() error {
+	if err := checkGT("C0", cipher.c0); err != nil {
+		return err
+	}
+	if err := checkG2("C1", -1, cipher.c1); err != nil {
+		return err
+	}
+	if err := checkG1("C2", -1, cipher.c2); err != nil {
+		return err
+	}
+	if err := checkG1("C3", -1, cipher.c3); err != nil {
+		return err
+	}
+	return nil
+}
+
+// check that P is a member of G1 and not the identity
+// i is the position of P in its slice, or -1 for single elements
+func checkG1(name string, i int, P *BN254.ECP) error {
+	if P == nil || P.Is_infinity() || !BN254.G1member(P) {
+		return invalidElement(name, i, "G1")
+	}
+	return nil
+}
+
+// check that P is a member of G2 and not the identity
+func checkG2(name string, i int, P *BN254.ECP2) error {
+	if P == nil || P.Is_infinity() || !BN254.G2member(P) {
+		return invalidElement(name, i, "G2")
+	}
+	return nil
+}
+
+// check that m is a member of GT and not the identity
+func checkGT(name string, m *BN254.FP12) error {
+	if m == nil || m.Isunity() || !BN254.GTmember(m) {
+		return invalidElement(name, -1, "GT")
+	}
+	return nil
+}
+
+func invalidElement(name string, i int, group string) error {
+	if i >= 0 {
+		return fmt.Errorf("ERROR: %s for i = %d is not a valid element of %s", name, i+1, group)
+	}
+	return fmt.Errorf("ERROR: %s is not a valid element of %s", name, group)
+}
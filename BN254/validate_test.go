@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/miracl/core/go/core/BN254"
+)
+
+func TestSkFromBytesValidates(t *testing.T) {
+	l := 8
+	pubKey, mk := setup(l)
+	id := randomID(l)
+	secKey := keyGen(id, mk, pubKey)
+
+	if _, err := skFromBytes(secKey.toBytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	secKey.yOdd[3] = BN254.NewECP() // identity
+	if _, err := skFromBytes(secKey.toBytes()); err == nil {
+		t.Error("secret key with the identity in y_2i-1 was accepted")
+	}
+}
+
+func TestSkQValidate(t *testing.T) {
+	pubKey, mk, err := setupQ(6, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secKey, err := keyGenQ("012321", mk, pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := secKey.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	y := secKey.yelements[2][1]
+	secKey.yelements[2][1] = BN254.NewECP()
+	if err := secKey.Validate(); err == nil {
+		t.Error("secret key with the identity in y_i,c was accepted")
+	}
+	secKey.yelements[2][1] = y
+
+	secKey.yelements[2] = secKey.yelements[2][:3]
+	if err := secKey.Validate(); err == nil {
+		t.Error("secret key with a missing symbol was accepted")
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/miracl/core/go/core/BN254"
+)
+
+func TestWipe(t *testing.T) {
+	q := BN254.NewBIGints(BN254.CURVE_Order)
+	x := BN254.Randomnum(q, rng)
+	wipeBIG(x)
+	if !x.IsZilch() {
+		t.Error("wipeBIG left a non-zero BIG")
+	}
+
+	P := BN254.G1mul(BN254.ECP_generator(), BN254.Randomnum(q, rng))
+	wipeECP(P)
+	if !P.Is_infinity() {
+		t.Error("wipeECP left a point other than infinity")
+	}
+
+	Q := BN254.G2mul(BN254.ECP2_generator(), BN254.Randomnum(q, rng))
+	wipeECP2(Q)
+	if !Q.Is_infinity() {
+		t.Error("wipeECP2 left a point other than infinity")
+	}
+}
+
+func TestLockedMK(t *testing.T) {
+	_, mk := setup(4)
+	want := BN254.NewECP()
+	want.Copy(mk)
+
+	lk, err := lockMK(mk)
+	if err != nil {
+		t.Log("no locked memory, testing the heap fallback:", err)
+	}
+	if !mk.Is_infinity() {
+		t.Error("lockMK did not wipe mk")
+	}
+	if !lk.get().Equals(want) {
+		t.Error("lockMK did not keep the master key")
+	}
+
+	buf := lk.buf
+	lk.wipe()
+	if !isZero(buf) {
+		t.Error("wipe left master key bytes behind")
+	}
+	if err := lk.Destroy(); err != nil {
+		t.Error(err)
+	}
+	if lk.buf != nil {
+		t.Error("Destroy kept the buffer")
+	}
+}
+
+// lockedFree unmaps locked buffers, so their content can only be checked on a heap key
+func TestLockedMKDestroy(t *testing.T) {
+	buf := make([]byte, g1Bytes)
+	BN254.ECP_generator().ToBytes(buf, true)
+
+	lk := &lockedMK{buf: buf}
+	if err := lk.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+	if !isZero(buf) {
+		t.Error("Destroy left master key bytes behind")
+	}
+}
+
+// check that all bytes of buf are 0
+func isZero(buf []byte) bool {
+	for _, b := range buf {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
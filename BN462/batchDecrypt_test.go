@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/miracl/core/go/core/BN462"
+)
+
+func TestDecryptBatch(t *testing.T) {
+	l := 16
+	pubKey, mk := setup(l)
+	id, s1 := genSubset(l, 0.5)
+	_, s2 := genSubset(l, 0.9)
+	s2.cl = id // a second subset covering id
+	revoked := &subset{s1.cl, id}
+	secKey := keyGen(id, mk, pubKey)
+
+	subsets := []*subset{s1, s2, s1, revoked, s2, s1}
+	messages := make([]*BN462.FP12, len(subsets))
+	queue := make([]*queuedHdr, len(subsets)+1)
+	for i, s := range subsets {
+		messages[i] = createRandomM(pubKey)
+		queue[i] = &queuedHdr{s, encrypt(s, pubKey, messages[i])}
+	}
+	// queue[len(subsets)] stays nil
+
+	mes, errs := decryptBatch(id, secKey, queue)
+
+	for i, s := range subsets {
+		if s == revoked {
+			if errs[i] != errRevoked {
+				t.Errorf("header %d: got %v for a revoked ID, want errRevoked", i, errs[i])
+			}
+			continue
+		}
+		if errs[i] != nil {
+			t.Errorf("header %d: %v", i, errs[i])
+		} else if !mes[i].Equals(messages[i]) {
+			t.Errorf("header %d: wrong message", i)
+		}
+	}
+	if errs[len(subsets)] == nil {
+		t.Error("missing header was not reported")
+	}
+}
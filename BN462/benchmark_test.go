@@ -0,0 +1,240 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/miracl/core/go/core/BN462"
+)
+
+// ----------- Benchmarks
+// go test -run '^$' -bench . -benchmem -args -l 8,16,32,64,128,256 -density 0,0.5,0.9
+// Every benchmark runs once per l, those of encrypt and decrypt once per l and
+// density. Besides ns/op, B/op and allocs/op each reports the group operations
+// of a single call, counted by the wrappers in opcount.go.
+// cmd/benchfmt turns the output into CSV or JSON.
+
+var benchLs = flag.String("l", "8,16,32,64,128", "comma separated list of ID lengths")
+var benchDensities = flag.String("density", "0,0.5,0.9", "comma separated list of wildcard shares in CL and RL")
+
+// keys and a header for one l and density
+type benchFixture struct {
+	pubKey  *pk
+	mk      *BN462.ECP
+	id      string
+	s       *subset
+	secKey  *sk
+	message *BN462.FP12
+	cipher  *hdr
+}
+
+// public and master key per l, shared by all benchmarks
+var benchKeys = map[int]*benchFixture{}
+
+func BenchmarkSetup(b *testing.B) {
+	for _, l := range parseLs(b) {
+		b.Run(fmt.Sprintf("l=%d", l), func(b *testing.B) {
+			benchOps(b, func() {
+				setup(l)
+			})
+		})
+	}
+}
+
+func BenchmarkKeyGen(b *testing.B) {
+	for _, l := range parseLs(b) {
+		b.Run(fmt.Sprintf("l=%d", l), func(b *testing.B) {
+			fx := keysFor(l)
+			id := randomID(l)
+			benchOps(b, func() {
+				keyGen(id, fx.mk, fx.pubKey)
+			})
+		})
+	}
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	benchSubsets(b, func(b *testing.B, fx *benchFixture) {
+		benchOps(b, func() {
+			encrypt(fx.s, fx.pubKey, fx.message)
+		})
+	})
+}
+
+func BenchmarkEncryptCached(b *testing.B) {
+	benchSubsets(b, func(b *testing.B, fx *benchFixture) {
+		ec := newEncContext(fx.pubKey, 1)
+		ec.encrypt(fx.s, fx.message)
+		benchOps(b, func() {
+			ec.encrypt(fx.s, fx.message)
+		})
+	})
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	benchSubsets(b, func(b *testing.B, fx *benchFixture) {
+		benchOps(b, func() {
+			decrypt(fx.s, fx.id, fx.secKey, fx.cipher)
+		})
+	})
+}
+
+func BenchmarkDecryptCached(b *testing.B) {
+	benchSubsets(b, func(b *testing.B, fx *benchFixture) {
+		dec, err := newDecryptor(fx.id, fx.secKey, 1)
+		if err != nil {
+			b.Fatal(err)
+		}
+		dec.decrypt(fx.s, fx.cipher)
+		benchOps(b, func() {
+			dec.decrypt(fx.s, fx.cipher)
+		})
+	})
+}
+
+func BenchmarkDecryptInto(b *testing.B) {
+	benchSubsets(b, func(b *testing.B, fx *benchFixture) {
+		sc := newDecryptScratch(len(fx.id))
+		mes := BN462.NewFP12int(1)
+		benchOps(b, func() {
+			decryptInto(mes, fx.s, fx.id, fx.secKey, fx.cipher, sc)
+		})
+	})
+}
+
+// run f b.N times after counting the group operations of one call
+func benchOps(b *testing.B, f func()) {
+	ops := countOps(f)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f()
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(ops.G1Add), "G1add/op")
+	b.ReportMetric(float64(ops.G1Mul), "G1mul/op")
+	b.ReportMetric(float64(ops.G2Add), "G2add/op")
+	b.ReportMetric(float64(ops.G2Mul), "G2mul/op")
+	b.ReportMetric(float64(ops.GTMul), "GTmul/op")
+	b.ReportMetric(float64(ops.GTPow), "GTpow/op")
+	b.ReportMetric(float64(ops.Miller), "Miller/op")
+	b.ReportMetric(float64(ops.Fexp), "Fexp/op")
+}
+
+// run bench as sub-benchmark for every l and density
+func benchSubsets(b *testing.B, bench func(b *testing.B, fx *benchFixture)) {
+	densities, err := parseFloats(*benchDensities)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, l := range parseLs(b) {
+		for _, density := range densities {
+			if density < 0 || density > 1 {
+				b.Fatalf("density %v is not between 0 and 1", density)
+			}
+			b.Run(fmt.Sprintf("l=%d/density=%v", l, density), func(b *testing.B) {
+				fx := *keysFor(l)
+				fx.id, fx.s = genSubset(l, density)
+				fx.secKey = keyGen(fx.id, fx.mk, fx.pubKey)
+				fx.message = createRandomM(fx.pubKey)
+				fx.cipher = encrypt(fx.s, fx.pubKey, fx.message)
+				bench(b, &fx)
+			})
+		}
+	}
+}
+
+// public and master key for l, created on first use
+func keysFor(l int) *benchFixture {
+	fx, ok := benchKeys[l]
+	if !ok {
+		fx = &benchFixture{}
+		fx.pubKey, fx.mk = setup(l)
+		benchKeys[l] = fx
+	}
+	return fx
+}
+
+// ID lengths given by -l
+func parseLs(b *testing.B) []int {
+	ls, err := parseInts(*benchLs)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return ls
+}
+
+// parse a comma separated list of ints
+func parseInts(list string) ([]int, error) {
+	result := []int{}
+	for _, field := range strings.Split(list, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
+// parse a comma separated list of floats
+func parseFloats(list string) ([]float64, error) {
+	result := []float64{}
+	for _, field := range strings.Split(list, ",") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, f)
+	}
+	return result, nil
+}
+
+// function to generate a test ID and a subset covering it for any ID length l
+// density is the share of wildcards in CL and RL. Fixed CL positions match the ID,
+// fixed RL positions are random, but at least one differs from the ID so it is not revoked (d > 0)
+func genSubset(l int, density float64) (id string, s *subset) {
+	id = randomID(l)
+	cl := []byte(id)
+	rl := []byte(randomID(l))
+
+	for i := 0; i < l; i++ {
+		if randomFloat() < density {
+			cl[i] = '*'
+		}
+		if randomFloat() < density {
+			rl[i] = '*'
+		}
+	}
+
+	d := 0
+	for i := 0; i < l; i++ {
+		if rl[i] != '*' && rl[i] != id[i] {
+			d++
+		}
+	}
+	if d == 0 && l > 0 {
+		i := int(rng.GetByte()) % l
+		rl[i] = '0' + '1' - id[i]
+	}
+
+	return id, &subset{string(cl), string(rl)}
+}
+
+// function to generate a random binary ID of length l
+func randomID(l int) string {
+	id := make([]byte, l)
+	for i := 0; i < l; i++ {
+		id[i] = '0' + rng.GetByte()&1
+	}
+	return string(id)
+}
+
+// random number in [0,1)
+func randomFloat() float64 {
+	return float64(int(rng.GetByte())<<8|int(rng.GetByte())) / 65536
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+// ----------- Structs
+
+// Bounded cache keyed by subset (CL,RL)
+// once full, the least recently used subset is dropped
+type subsetCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // most recently used first
+	entries map[subset]*list.Element
+}
+
+type cacheEntry struct {
+	key   subset
+	value interface{}
+}
+
+// create a cache holding at most size subsets
+func newSubsetCache(size int) *subsetCache {
+	if size < 1 {
+		size = 1
+	}
+	return &subsetCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[subset]*list.Element),
+	}
+}
+
+// look up the value for s
+func (c *subsetCache) get(s *subset) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[*s]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cacheEntry).value, true
+}
+
+// store value for s, dropping the least recently used subset if the cache is full
+func (c *subsetCache) add(s *subset, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[*s]; ok {
+		e.Value.(*cacheEntry).value = value
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[*s] = c.order.PushFront(&cacheEntry{*s, value})
+	if c.order.Len() > c.size {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.entries, last.Value.(*cacheEntry).key)
+	}
+}
+
+// drop s from the cache
+func (c *subsetCache) remove(s *subset) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[*s]; ok {
+		c.order.Remove(e)
+		delete(c.entries, *s)
+	}
+}
+
+// drop all subsets from the cache
+func (c *subsetCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[subset]*list.Element)
+}
+
+// number of cached subsets
+func (c *subsetCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/miracl/core/go/core/BN462"
+)
+
+func TestCheckHdr(t *testing.T) {
+	l := 8
+	pubKey, _ := setup(l)
+	s := &subset{cl: "*1****10", rl: "*****110"}
+	cipher, _ := encapsulate(s, pubKey)
+
+	if err := checkHdr(pubKey, s, cipher); err != nil {
+		t.Fatal(err)
+	}
+	rerandomized, err := rerandomizeHdr(pubKey, s, cipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkHdr(pubKey, s, rerandomized); err != nil {
+		t.Errorf("re-randomized header: %v", err)
+	}
+
+	// a valid header, but for other CL and RL
+	if err := checkHdr(pubKey, &subset{cl: "*1****11", rl: s.rl}, cipher); err != errMalformedHdr {
+		t.Errorf("other CL: got %v, want errMalformedHdr", err)
+	}
+	if err := checkHdr(pubKey, &subset{cl: s.cl, rl: "*****111"}, cipher); err != errMalformedHdr {
+		t.Errorf("other RL: got %v, want errMalformedHdr", err)
+	}
+
+	// C2 and C3 of a header with another t
+	other, _ := encapsulate(s, pubKey)
+	mixed := *cipher
+	mixed.c2 = other.c2
+	if err := checkHdr(pubKey, s, &mixed); err != errMalformedHdr {
+		t.Errorf("C2 with another t: got %v, want errMalformedHdr", err)
+	}
+	mixed = *cipher
+	mixed.c3 = other.c3
+	if err := checkHdr(pubKey, s, &mixed); err != errMalformedHdr {
+		t.Errorf("C3 with another t: got %v, want errMalformedHdr", err)
+	}
+
+	// invalid elements and subsets are rejected before the pairings
+	mixed = *cipher
+	mixed.c3 = BN462.NewECP()
+	if err := checkHdr(pubKey, s, &mixed); err == nil {
+		t.Error("header with C3 at infinity was accepted")
+	}
+	for _, bad := range []*subset{{cl: "*1****1", rl: "*****110"}, {cl: "*1****1x", rl: "*****110"}} {
+		if err := checkHdr(pubKey, bad, cipher); err == nil {
+			t.Errorf("subset %v was accepted", *bad)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/miracl/core/go/core/BN462"
+)
+
+// ----------- Structs
+
+// Caller-provided buffers for decryptInto
+// One scratch serves any number of decryptions for IDs of length l, but must
+// not be shared between goroutines.
+type decryptScratch struct {
+	l    int
+	inv  []*BN462.BIG // inv[d] = d^-1 mod q, so no BIG is needed per call
+	xy   *BN462.ECP
+	yAp  *BN462.ECP
+	c2Ap *BN462.ECP
+	inP  []bool // inP[i] is set if position i is in P, replaces the slices pRl and qRl
+	inQ  []bool
+}
+
+// create scratch buffers for IDs of length l
+func newDecryptScratch(l int) *decryptScratch {
+	q := BN462.NewBIGints(BN462.CURVE_Order)
+	inv := make([]*BN462.BIG, l+1)
+	for d := 1; d <= l; d++ {
+		inv[d] = BN462.NewBIGint(d)
+		inv[d].Invmodp(q) // d^-1
+	}
+	return &decryptScratch{
+		l:    l,
+		inv:  inv,
+		xy:   BN462.NewECP(),
+		yAp:  BN462.NewECP(),
+		c2Ap: BN462.NewECP(),
+		inP:  make([]bool, l),
+		inQ:  make([]bool, l),
+	}
+}
+
+// Decrypt(S=(CL,RL),ID,SK_ID,HdrS) -> M or error, writing M into mes
+// Same as decrypt, but the intermediate values of this package live in sc; for
+// d = 1 the two scalar multiplications are skipped entirely.
+// It is NOT allocation-free, and can not be on top of MIRACL: G1mul, Ate2,
+// Fexp and the subgroup checks of Validate return fresh values and allocate
+// internally, and MIRACL has no variants writing into caller buffers. Only the
+// copies, slices and BIGs of decrypt are saved. TestDecryptIntoAllocs asserts
+// zero allocations and is skipped with the measured count until that changes.
+// The header is validated like in decrypt, secKey once when it is loaded.
+func decryptInto(mes *BN462.FP12, s *subset, id string, secKey *sk, cipher *hdr, sc *decryptScratch) error {
+	if err := cipher.Validate(); err != nil {
+		return err
+	}
+	l := len(id)
+	if l != sc.l {
+		return errors.New("ERROR: scratch buffers do not match the ID length")
+	}
+	if len(s.cl) != l || len(s.rl) != l || len(secKey.xelements) != l {
+		return errLength
+	}
+
+	// ----------- Decrypt 1 - 3
+	// P = bits that are different from revoked list, Q = bits that are equal to it, d = |P|
+	d := 0
+	for i := 0; i < l; i++ {
+		sc.inP[i] = s.rl[i] != '*' && id[i] != s.rl[i]
+		sc.inQ[i] = s.rl[i] != '*' && id[i] == s.rl[i]
+		if sc.inP[i] {
+			d++
+		}
+	}
+
+	// ----------- Decrypt 4
+	if d == 0 {
+		return errRevoked
+	}
+
+	// compute x'
+	sc.xy.Copy(secKey.x0)
+	for i := 0; i < l; i++ {
+		if s.cl[i] == '*' {
+			g1add(sc.xy, secKey.xelements[i])
+		}
+	}
+
+	// compute y'
+	sc.yAp.Copy(secKey.y0)
+	for i := 0; i < l; i++ {
+		if sc.inP[i] {
+			g1add(sc.yAp, secKey.yOdd[i])
+		} else if sc.inQ[i] {
+			g1add(sc.yAp, secKey.yEven[i])
+		}
+	}
+
+	// x' * y'^(d^-1) and C2 * C3^(d^-1)
+	sc.c2Ap.Copy(cipher.c2)
+	if d == 1 {
+		g1add(sc.xy, sc.yAp)
+		g1add(sc.c2Ap, cipher.c3)
+	} else {
+		g1add(sc.xy, g1mul(sc.yAp, sc.inv[d]))
+		g1add(sc.c2Ap, g1mul(cipher.c3, sc.inv[d]))
+	}
+
+	// decrypt message: m = c0 * e(x'*y', C1)^-1 * e(C2*C3^(d-1), z)
+	// e(x'*y', C1)^-1 = e((x'*y')^-1, C1), so both pairings share one final exponentiation
+	sc.xy.Neg()
+	e := ate2(cipher.c1, sc.xy, secKey.z, sc.c2Ap)
+	e = fexp(e)
+
+	mes.Copy(cipher.c0)
+	gtmul(mes, e)
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"github.com/miracl/core/go/core/BN462"
+)
+
+// ----------- Structs
+
+// Decryptor of a single device
+// x' * y' and d^-1 of Decrypt 1 - 4 only depend on the subset, so they are
+// remembered for the most recently seen subsets and consecutive headers for
+// the same subset only pay for the pairings
+type decryptor struct {
+	id     string
+	secKey *sk
+	cache  *subsetCache
+}
+
+// cached result of decryptKey for one subset
+// err is kept as well, so a revoked device does not redo Decrypt 1 - 3 for every header
+type decryptEntry struct {
+	xy   *BN462.ECP
+	dExp *BN462.BIG
+	err  error
+}
+
+// create a decryptor remembering at most size subsets
+// the secret key is validated once here instead of on every decrypt
+func newDecryptor(id string, secKey *sk, size int) (*decryptor, error) {
+	if err := secKey.Validate(); err != nil {
+		return nil, err
+	}
+	return &decryptor{id: id, secKey: secKey, cache: newSubsetCache(size)}, nil
+}
+
+// Decrypt(S=(CL,RL),ID,SK_ID,HdrS) -> M or error for the device of dec
+func (dec *decryptor) decrypt(s *subset, cipher *hdr) (mes *BN462.FP12, err error) {
+	if err := cipher.Validate(); err != nil {
+		return nil, err
+	}
+	entry := dec.entry(s)
+	if entry.err != nil {
+		return nil, entry.err
+	}
+	return decryptWith(entry.xy, entry.dExp, dec.secKey.z, cipher), nil
+}
+
+// get the cached result of decryptKey for s, computing it if needed
+func (dec *decryptor) entry(s *subset) *decryptEntry {
+	if v, ok := dec.cache.get(s); ok {
+		return v.(*decryptEntry)
+	}
+
+	xy, dExp, err := decryptKey(s, dec.id, dec.secKey)
+	if xy != nil {
+		xy.Affine()
+	}
+	entry := &decryptEntry{xy, dExp, err}
+	dec.cache.add(s, entry)
+	return entry
+}
+
+// forget the cached values of s
+func (dec *decryptor) invalidate(s *subset) {
+	dec.cache.remove(s)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/miracl/core/go/core/BN462"
+)
+
+func TestDecryptorMatchesDecrypt(t *testing.T) {
+	l := 8
+	pubKey, mk := setup(l)
+	id, s := genSubset(l, 0.5)
+	secKey := keyGen(id, mk, pubKey)
+	dec, err := newDecryptor(id, secKey, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		cipher, message := encapsulate(s, pubKey)
+		want, err := decrypt(s, id, secKey, cipher)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mes, err := dec.decrypt(s, cipher)
+		if err != nil || !mes.Equals(want) || !mes.Equals(message) {
+			t.Errorf("header %d: cached decrypt differs from decrypt: %v", i, err)
+		}
+	}
+	if dec.cache.len() != 1 {
+		t.Errorf("%d subsets cached, want 1", dec.cache.len())
+	}
+}
+
+func TestDecryptorCachedErrors(t *testing.T) {
+	id := "01101010"
+	pubKey, mk := setup(len(id))
+	dec, err := newDecryptor(id, keyGen(id, mk, pubKey), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revoked := &subset{"*1****10", "*****010"}
+	cipher, _ := encapsulate(revoked, pubKey)
+	for i := 0; i < 2; i++ {
+		if _, err := dec.decrypt(revoked, cipher); err != errRevoked {
+			t.Errorf("call %d: got %v, want errRevoked", i, err)
+		}
+	}
+	v, ok := dec.cache.get(revoked)
+	if !ok || v.(*decryptEntry).err != errRevoked {
+		t.Fatal("errRevoked is not cached")
+	}
+
+	// the error comes from the cache, not from decryptKey
+	dec.cache.add(revoked, &decryptEntry{err: errLength})
+	if _, err := dec.decrypt(revoked, cipher); err != errLength {
+		t.Errorf("got %v, want the cached error", err)
+	}
+
+	uncovered := &subset{"1*******", "*******1"}
+	cipher, message := encapsulate(uncovered, pubKey)
+	for i := 0; i < 2; i++ {
+		mes, err := dec.decrypt(uncovered, cipher)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mes.Equals(message) {
+			t.Errorf("not covered, call %d: decrypted the message", i)
+		}
+	}
+}
+
+func TestDecryptorInvalidate(t *testing.T) {
+	l := 8
+	pubKey, mk := setup(l)
+	id, s := genSubset(l, 0.5)
+	dec, err := newDecryptor(id, keyGen(id, mk, pubKey), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cipher, message := encapsulate(s, pubKey)
+
+	// a stale entry is used until s is invalidated
+	dec.cache.add(s, &decryptEntry{BN462.ECP_generator(), BN462.NewBIGint(1), nil})
+	if mes, err := dec.decrypt(s, cipher); err != nil || mes.Equals(message) {
+		t.Errorf("stale entry was not used: %v", err)
+	}
+	dec.invalidate(s)
+	if _, ok := dec.cache.get(s); ok {
+		t.Error("s is still cached after invalidate")
+	}
+	mes, err := dec.decrypt(s, cipher)
+	if err != nil || !mes.Equals(message) {
+		t.Errorf("no recomputation after invalidate: %v", err)
+	}
+}
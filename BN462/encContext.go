@@ -0,0 +1,59 @@
+package main
+
+import (
+	"github.com/miracl/core/go/core/BN462"
+)
+
+// ----------- Structs
+
+// Encryption context of a broadcaster for one public key
+// H(CL) and K(RL) only depend on the subset, so they are cached together with
+// their fixed-base tables (see fixedBase.go) instead of walking all l
+// positions of the public key on every call of encrypt. A cache hit saves the
+// up to 2l G1 additions of the aggregation, and c2 and c3 take one table
+// addition per nibble of t instead of a full scalar multiplication.
+// A cached subset holds two tables of 16 points per nibble of the group order.
+type encContext struct {
+	pubKey *pk
+	cache  *subsetCache
+}
+
+// cached H(CL) and K(RL) of one subset
+type aggregates struct {
+	hcl *g1Table
+	krl *g1Table
+}
+
+// create an encryption context caching at most size subsets
+func newEncContext(pubKey *pk, size int) *encContext {
+	return &encContext{pubKey: pubKey, cache: newSubsetCache(size)}
+}
+
+// Encrypt(S=(CL,RL), PK, and message M) -> Header HdrS) using the cached tables of H(CL) and K(RL)
+func (ec *encContext) encrypt(s *subset, message *BN462.FP12) (cipher *hdr) {
+	agg := ec.aggregates(s)
+	return encryptMul(func(t *BN462.BIG) (*BN462.ECP, *BN462.ECP) {
+		return agg.hcl.mul(t), agg.krl.mul(t)
+	}, ec.pubKey, message)
+}
+
+// get the tables of H(CL) and K(RL) for s, computing and caching them if needed
+func (ec *encContext) aggregates(s *subset) *aggregates {
+	if v, ok := ec.cache.get(s); ok {
+		return v.(*aggregates)
+	}
+
+	agg := &aggregates{newG1Table(aggregateH(s.cl, ec.pubKey)), newG1Table(aggregateK(s.rl, ec.pubKey))}
+	ec.cache.add(s, agg)
+	return agg
+}
+
+// drop the cached H(CL) and K(RL) of s
+func (ec *encContext) invalidate(s *subset) {
+	ec.cache.remove(s)
+}
+
+// drop all cached subsets
+func (ec *encContext) invalidateAll() {
+	ec.cache.clear()
+}
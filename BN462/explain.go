@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ----------- Explain
+// Diagnostic for support staff: works out why a device can or can not decrypt a
+// header for a subset, following the steps of decrypt but without any keys.
+
+// ----------- Structs
+
+type verdict int
+
+const (
+	verdictCovered verdict = iota
+	verdictRevoked
+	verdictNotCovered
+	verdictLengthMismatch
+	verdictInvalid
+)
+
+func (v verdict) String() string {
+	switch v {
+	case verdictCovered:
+		return "covered"
+	case verdictRevoked:
+		return "revoked"
+	case verdictNotCovered:
+		return "not covered"
+	case verdictLengthMismatch:
+		return "length mismatch"
+	}
+	return "invalid input"
+}
+
+// what decrypt does for an ID and a subset
+// positions are counted from 1 as in decrypt
+type explanation struct {
+	id         string
+	s          *subset
+	clMismatch []int // fixed CL positions that differ from the ID
+	p          []int // P: fixed RL positions that differ from the ID
+	q          []int // Q: fixed RL positions equal to the ID
+	d          int   // d = |P|
+	verdict    verdict
+	err        error // reason for length mismatch and invalid input
+}
+
+// Explain whether id can decrypt headers for s
+func explain(id string, s *subset) *explanation {
+	e := &explanation{id: id, s: s}
+	l := len(id)
+	if len(s.cl) != l || len(s.rl) != l {
+		e.verdict = verdictLengthMismatch
+		e.err = fmt.Errorf("ID has %d bits, CL %d and RL %d", l, len(s.cl), len(s.rl))
+		return e
+	}
+	if err := checkID(id, l); err != nil {
+		e.verdict, e.err = verdictInvalid, err
+		return e
+	}
+	if err := checkSubset(s, l); err != nil {
+		e.verdict, e.err = verdictInvalid, err
+		return e
+	}
+
+	for i := 0; i < l; i++ {
+		if s.cl[i] != '*' && s.cl[i] != id[i] {
+			e.clMismatch = append(e.clMismatch, i+1)
+		}
+		if s.rl[i] == '*' {
+			continue
+		}
+		if s.rl[i] != id[i] {
+			e.p = append(e.p, i+1)
+		} else {
+			e.q = append(e.q, i+1)
+		}
+	}
+	e.d = len(e.p)
+
+	switch {
+	case len(e.clMismatch) > 0:
+		e.verdict = verdictNotCovered
+	case e.d == 0:
+		e.verdict = verdictRevoked
+	default:
+		e.verdict = verdictCovered
+	}
+	return e
+}
+
+// human readable report
+func (e *explanation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ID: %s  CL: %s  RL: %s\n", e.id, e.s.cl, e.s.rl)
+	if e.err != nil {
+		fmt.Fprintf(&b, "Verdict: %s (%v)\n", e.verdict, e.err)
+		return b.String()
+	}
+
+	if len(e.clMismatch) == 0 {
+		b.WriteString("ID matches CL\n")
+	} else {
+		fmt.Fprintf(&b, "ID does not match CL at positions %v\n", e.clMismatch)
+	}
+	fmt.Fprintf(&b, "P (RL differs from ID) = %v\n", e.p)
+	fmt.Fprintf(&b, "Q (RL equals ID) = %v\n", e.q)
+	fmt.Fprintf(&b, "d = %d\n", e.d)
+
+	switch e.verdict {
+	case verdictCovered:
+		b.WriteString("Verdict: covered, the device can decrypt")
+	case verdictRevoked:
+		b.WriteString("Verdict: revoked, the ID matches every fixed position of RL (d = 0)")
+	case verdictNotCovered:
+		b.WriteString("Verdict: not covered, the ID is not in CL")
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/subtle"
+
+	"github.com/miracl/core/go/core/BN462"
+)
+
+// ----------- Fixed-Base Tables
+// A point P that is raised to many secret exponents is stored with its
+// multiples (j+1) * 16^i * P for every nibble position i and j = 0 ... 15, so
+// P^e is the sum of one table entry per nibble of e, without any doublings.
+// Like the windowed multiplication of MIRACL the entries are selected in
+// constant time: every entry of a row is read and copied with
+// subtle.ConstantTimeCopy, so memory accesses do not depend on e.
+// The +1 keeps the point at infinity out of the table, the sum of the 16^i * P
+// is subtracted again at the end.
+
+// size of an uncompressed ECP
+const g1RawBytes = 2*fpBytes + 1
+
+// ----------- Structs
+
+// fixed-base table of a point of G1
+type g1Table struct {
+	rows   [][]byte   // row i holds (j+1) * 16^i * P for j = 0 ... 15, uncompressed
+	offset *BN462.ECP // -(sum of 16^i * P over all rows)
+}
+
+// build the table of P, which takes 16 points per nibble of the group order
+func newG1Table(P *BN462.ECP) *g1Table {
+	q := BN462.NewBIGints(BN462.CURVE_Order)
+	n := (q.Nbits() + 3) / 4
+
+	tb := &g1Table{rows: make([][]byte, n), offset: BN462.NewECP()}
+	base := BN462.NewECP() // 16^i * P
+	base.Copy(P)
+	for i := 0; i < n; i++ {
+		row := make([]byte, 16*g1RawBytes)
+		entry := BN462.NewECP()
+		entry.Copy(base)
+		for j := 0; j < 16; j++ {
+			if j > 0 {
+				entry.Add(base)
+			}
+			affine := BN462.NewECP()
+			affine.Copy(entry)
+			affine.Affine()
+			affine.ToBytes(row[j*g1RawBytes:(j+1)*g1RawBytes], false)
+		}
+		tb.rows[i] = row
+
+		tb.offset.Add(base)
+		for k := 0; k < 4; k++ {
+			base.Dbl()
+		}
+	}
+	tb.offset.Neg()
+	tb.offset.Affine()
+	return tb
+}
+
+// P^e from the table, e has to be reduced mod q
+// counted as one G1 multiplication like g1mul
+func (tb *g1Table) mul(e *BN462.BIG) *BN462.ECP {
+	count(&ops.G1Mul, 1)
+
+	eb := make([]byte, BN462.MODBYTES)
+	e.ToBytes(eb)
+	entry := make([]byte, g1RawBytes)
+	defer wipeBytes(eb)
+	defer wipeBytes(entry)
+
+	R := BN462.NewECP()
+	R.Copy(tb.offset)
+	for i, row := range tb.rows {
+		d := int(eb[len(eb)-1-i/2]>>(4*uint(i%2))) & 15
+		for j := 0; j < 16; j++ {
+			subtle.ConstantTimeCopy(subtle.ConstantTimeEq(int32(j), int32(d)), entry, row[j*g1RawBytes:(j+1)*g1RawBytes])
+		}
+		R.Add(BN462.ECP_fromBytes(entry))
+	}
+	return R
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"sync/atomic"
+
+	"github.com/miracl/core/go/core/BN462"
+)
+
+// ----------- Operation Counting
+// The algorithms call the group operations through the wrappers below, which
+// count them while counting is enabled. This shows why an algorithm costs what
+// it does on a specific curve, not just how long it takes.
+
+// number of group operations
+type opCounts struct {
+	G1Add  int64 // additions in G1
+	G1Mul  int64 // scalar multiplications in G1
+	G2Add  int64 // additions in G2
+	G2Mul  int64 // scalar multiplications in G2
+	GTMul  int64 // multiplications in GT
+	GTPow  int64 // exponentiations in GT
+	Miller int64 // Miller loops, one per pairing even if pairings are combined
+	Fexp   int64 // final exponentiations
+}
+
+// ----------- Package Scope Variables
+var counting int32 // 1 while operations are counted
+var ops opCounts
+
+// Run f with counting enabled and return the group operations it performed
+// including those of goroutines started by f. Not safe for concurrent use.
+func countOps(f func()) opCounts {
+	// workers of an earlier call may still be counting, so reset atomically too
+	for _, counter := range []*int64{&ops.G1Add, &ops.G1Mul, &ops.G2Add, &ops.G2Mul, &ops.GTMul, &ops.GTPow, &ops.Miller, &ops.Fexp} {
+		atomic.StoreInt64(counter, 0)
+	}
+	atomic.StoreInt32(&counting, 1)
+	f()
+	atomic.StoreInt32(&counting, 0)
+
+	return opCounts{
+		G1Add:  atomic.LoadInt64(&ops.G1Add),
+		G1Mul:  atomic.LoadInt64(&ops.G1Mul),
+		G2Add:  atomic.LoadInt64(&ops.G2Add),
+		G2Mul:  atomic.LoadInt64(&ops.G2Mul),
+		GTMul:  atomic.LoadInt64(&ops.GTMul),
+		GTPow:  atomic.LoadInt64(&ops.GTPow),
+		Miller: atomic.LoadInt64(&ops.Miller),
+		Fexp:   atomic.LoadInt64(&ops.Fexp),
+	}
+}
+
+// increase counter by n if counting is enabled
+func count(counter *int64, n int64) {
+	if atomic.LoadInt32(&counting) == 1 {
+		atomic.AddInt64(counter, n)
+	}
+}
+
+// P = P + Q in G1
+func g1add(P *BN462.ECP, Q *BN462.ECP) {
+	count(&ops.G1Add, 1)
+	P.Add(Q)
+}
+
+// P^e in G1
+func g1mul(P *BN462.ECP, e *BN462.BIG) *BN462.ECP {
+	count(&ops.G1Mul, 1)
+	return BN462.G1mul(P, e)
+}
+
+// P = P + Q in G2
+func g2add(P *BN462.ECP2, Q *BN462.ECP2) {
+	count(&ops.G2Add, 1)
+	P.Add(Q)
+}
+
+// P^e in G2
+func g2mul(P *BN462.ECP2, e *BN462.BIG) *BN462.ECP2 {
+	count(&ops.G2Mul, 1)
+	return BN462.G2mul(P, e)
+}
+
+// x = x * y in GT
+func gtmul(x *BN462.FP12, y *BN462.FP12) {
+	count(&ops.GTMul, 1)
+	x.Mul(y)
+}
+
+// x^e in GT
+func gtpow(x *BN462.FP12, e *BN462.BIG) *BN462.FP12 {
+	count(&ops.GTPow, 1)
+	return x.Pow(e)
+}
+
+// Miller loop of e(Q, P)
+func ate(P *BN462.ECP2, Q *BN462.ECP) *BN462.FP12 {
+	count(&ops.Miller, 1)
+	return BN462.Ate(P, Q)
+}
+
+// combined Miller loop of e(Q, P) * e(S, R)
+func ate2(P *BN462.ECP2, Q *BN462.ECP, R *BN462.ECP2, S *BN462.ECP) *BN462.FP12 {
+	count(&ops.Miller, 2)
+	return BN462.Ate2(P, Q, R, S)
+}
+
+// final exponentiation
+func fexp(m *BN462.FP12) *BN462.FP12 {
+	count(&ops.Fexp, 1)
+	return BN462.Fexp(m)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/miracl/core/go/core"
+	"github.com/miracl/core/go/core/BN462"
+)
+
+// ----------- Package Scope Variables
+// number of goroutines used for concurrent work such as Setup 4
+var numWorkers = runtime.NumCPU()
+
+// Generate n slices of l random group elements g1^x, each with a fresh random exponent x
+// The n*l scalar multiplications are independent, so they are split across goroutines.
+// rng must not be shared between goroutines, so each one gets its own stream seeded from rng.
+func genElements(ctx context.Context, g1 *BN462.ECP, n int, l int, progress func(done, total int)) ([][]*BN462.ECP, error) {
+	total := n * l
+	elements := make([][]*BN462.ECP, n)
+	for j := 0; j < n; j++ {
+		elements[j] = make([]*BN462.ECP, l)
+	}
+	if total == 0 {
+		return elements, nil
+	}
+
+	w := numWorkers
+	if w > total {
+		w = total
+	}
+	if w < 1 {
+		w = 1
+	}
+
+	// seed all streams up front, rng itself is only used from this goroutine
+	streams := make([]*core.RAND, w)
+	for i := 0; i < w; i++ {
+		streams[i] = newRandStream()
+	}
+
+	jobs := make(chan int)
+	done := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < w; i++ {
+		wg.Add(1)
+		go func(stream *core.RAND) {
+			defer wg.Done()
+			q := BN462.NewBIGints(BN462.CURVE_Order)
+			g := BN462.NewECP()
+			g.Copy(g1) // every goroutine works on its own copy of the generator
+			for job := range jobs {
+				x := BN462.Randomnum(q, stream)
+				elements[job/l][job%l] = g1mul(g, x)
+				wipeBIG(x)
+				done <- job
+			}
+		}(streams[i])
+	}
+
+	// hand out jobs until all are taken or ctx is cancelled
+	go func() {
+		defer close(jobs)
+		for job := 0; job < total; job++ {
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	finished := 0
+	for range done {
+		finished++
+		if progress != nil {
+			progress(finished, total)
+		}
+	}
+	if finished < total {
+		return nil, ctx.Err()
+	}
+	return elements, nil
+}
+
+// Create a new random number generator seeded from rng
+// gives a goroutine its own independent stream of random numbers
+func newRandStream() *core.RAND {
+	var raw [128]byte
+	for i := 0; i < 128; i++ {
+		raw[i] = rng.GetByte()
+	}
+
+	stream := core.NewRAND()
+	stream.Clean()
+	stream.Seed(128, raw[:])
+	return stream
+}
+
+// Call f(i) for i = 0 ... n-1, spread across numWorkers goroutines
+// f must be safe to call concurrently
+func parallelFor(n int, f func(i int)) {
+	w := numWorkers
+	if w > n {
+		w = n
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < w; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				f(job)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
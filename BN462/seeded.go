@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/miracl/core/go/core"
+	"github.com/miracl/core/go/core/BN462"
+)
+
+// ----------- Seeded Public Parameters
+// h0, k0 and all h/k elements can be derived from a public seed by hashing to
+// G1 instead of raising g1 to secret exponents. Nobody knows their discrete
+// logarithms, and PK only needs the seed, g2 and omega to be shipped: every
+// broadcaster rebuilds the 4l+2 points locally.
+// extendPK works on seeded parameters as well, it only needs k0. The new h
+// elements are k0^eta with eta known to the authority though, so the extended
+// PK is no longer derived from the seed and has to be shipped in full.
+
+// domain separation tag for hashing to G1
+const seedDST = "BESTIE-PARAMS-V02-BN462"
+
+// largest ID length accepted by seededPKFromBytes, as every position costs four hashes to G1
+const maxSeededL = 4096
+
+// ----------- Structs
+
+// public key whose G1 elements are derived from seed
+type seededPK struct {
+	seed   []byte
+	pubKey *pk
+}
+
+// Setup with hash derived public parameters (l, seed) -> PK, MK
+// a random 32 byte seed is chosen if seed is nil
+func setupSeeded(l int, seed []byte) (*seededPK, *BN462.ECP, error) {
+	if seed == nil {
+		seed = make([]byte, 32)
+		for i := range seed {
+			seed[i] = rng.GetByte()
+		}
+	}
+
+	q := BN462.NewBIGints(BN462.CURVE_Order)
+	g1 := BN462.ECP_generator()
+	g2 := BN462.ECP2_generator()
+
+	alpha := BN462.Randomnum(q, rng)
+	defer wipeBIG(alpha)
+	mk := g1mul(g1, alpha)
+	omega := gtpow(fexp(ate(g2, g1)), alpha)
+
+	pubKey := seededElements(seed, l)
+	pubKey.g2 = g2
+	pubKey.omega = omega
+	return &seededPK{seed, pubKey}, mk, nil
+}
+
+// derive PK apart from g2 and omega from seed
+func seededElements(seed []byte, l int) *pk {
+	pubKey := &pk{
+		p:          BN462.NewBIGints(BN462.Modulus),
+		g1:         BN462.ECP_generator(),
+		h0:         hashToG1(seed, "h0"),
+		k0:         hashToG1(seed, "k0"),
+		helements0: make([]*BN462.ECP, l),
+		helements1: make([]*BN462.ECP, l),
+		kelements0: make([]*BN462.ECP, l),
+		kelements1: make([]*BN462.ECP, l),
+	}
+
+	elements := [][]*BN462.ECP{pubKey.helements0, pubKey.helements1, pubKey.kelements0, pubKey.kelements1}
+	names := []string{"h%d,0", "h%d,1", "k%d,0", "k%d,1"}
+	parallelFor(4*l, func(job int) {
+		j, i := job/l, job%l
+		elements[j][i] = hashToG1(seed, fmt.Sprintf(names[j], i+1))
+	})
+	return pubKey
+}
+
+// hash seed and label to a point of G1
+// the seed is length prefixed, so no two (seed, label) pairs give the same input
+// two field elements are mapped to the curve and added, as in MIRACL's hash to curve
+func hashToG1(seed []byte, label string) *BN462.ECP {
+	q := BN462.NewBIGints(BN462.Modulus)
+	n := int(BN462.MODBYTES) + BN462.AESKEY // enough bytes for a uniform field element
+
+	msg := appendLen(nil, len(seed))
+	msg = append(append(msg, seed...), label...)
+	okm := core.XMD_Expand(core.MC_SHA2, BN462.HASH_TYPE, 2*n, []byte(seedDST), msg)
+
+	P := BN462.NewECP()
+	for j := 0; j < 2; j++ {
+		u := BN462.NewFPbig(BN462.DBIG_fromBytes(okm[j*n : (j+1)*n]).Mod(q))
+		P.Add(BN462.ECP_map2point(u))
+	}
+	P.Cfp()
+	P.Affine()
+	return P
+}
+
+// serialise the seeded public key: l, seed length, seed, g2, omega
+func (spk *seededPK) toBytes() []byte {
+	buf := make([]byte, 0, 8+len(spk.seed)+g2Bytes+gtBytes)
+	buf = appendLen(buf, len(spk.pubKey.helements0))
+	buf = appendLen(buf, len(spk.seed))
+	buf = append(buf, spk.seed...)
+	buf = appendG2(buf, spk.pubKey.g2)
+	return appendGT(buf, spk.pubKey.omega)
+}
+
+// deserialise a seeded public key, rebuild its elements and validate it
+func seededPKFromBytes(b []byte) (*seededPK, error) {
+	r := &byteReader{b: b}
+	l := r.uint32()
+	seed := append([]byte{}, r.next(r.uint32())...)
+	g2 := r.g2()
+	omega := r.gt()
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	if len(seed) == 0 {
+		return nil, errors.New("ERROR: empty seed")
+	}
+	if l > maxSeededL {
+		return nil, fmt.Errorf("ERROR: ID length %d is larger than %d", l, maxSeededL)
+	}
+
+	pubKey := seededElements(seed, l)
+	pubKey.g2 = g2
+	pubKey.omega = omega
+	if err := pubKey.Validate(); err != nil {
+		return nil, err
+	}
+	return &seededPK{seed, pubKey}, nil
+}
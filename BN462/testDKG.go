@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// func main() {
+
+// 	// Initialise Random number generator
+// 	initRNG()
+
+// 	id := "01101010"
+// 	s := &subset{cl: "*1****10", rl: "*****110"}
+
+// 	testDKG(id, s, 3, 5)
+// }
+
+// Function to simulate a t-of-n distributed setup with in-process parties,
+// followed by a threshold KeyGen with the resulting shares
+func testDKG(id string, s *subset, t int, n int) {
+
+	fmt.Println("\n")
+	fmt.Println("-------  Distributed Setup  ---------")
+	fmt.Println("Parties: ", n, " Threshold: ", t)
+
+	pubKey, shares, err := runDKG(len(id), t, n)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Public key valid: ", pubKey.Validate() == nil)
+
+	parts := make([]*partialKey, t)
+	for j := 0; j < t; j++ {
+		parts[j] = partialKeyGen(id, shares[j], pubKey)
+	}
+	secKey, err := combineKeys(parts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	message := createRandomM(pubKey)
+	cipher := encrypt(s, pubKey, message)
+	mes, err := decrypt(s, id, secKey, cipher)
+	fmt.Println("Key verifies: ", verifyKey(pubKey, id, secKey) == nil,
+		" Message decrypted: ", err == nil && message.Equals(mes))
+
+	// a dealer sending a wrong share is caught by its recipient
+	party, _ := newDKGParty(1, t, n, len(id))
+	dealer, _ := newDKGParty(2, t, n, len(id))
+	fmt.Println("Wrong share detected: ", party.receive(dealer.reveal(), dealer.shareFor(3)) != nil)
+}
+
+// run all rounds of the distributed setup for n parties in this process
+// every party checks the transcript itself, so all of them have to agree on PK
+func runDKG(l int, t int, n int) (*pk, []*mkShare, error) {
+	parties := make([]*dkgParty, n)
+	for j := 0; j < n; j++ {
+		party, err := newDKGParty(j+1, t, n, l)
+		if err != nil {
+			return nil, nil, err
+		}
+		parties[j] = party
+	}
+
+	// Round 1: commitments
+	tr := &dkgTranscript{}
+	for _, party := range parties {
+		tr.hashes = append(tr.hashes, party.commitment())
+	}
+
+	// Round 2: reveal contributions and deal shares
+	for _, party := range parties {
+		tr.contributions = append(tr.contributions, party.reveal())
+	}
+	for _, dealer := range parties {
+		for _, party := range parties {
+			if err := party.receive(dealer.reveal(), dealer.shareFor(party.index)); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	// Finish
+	var pubKey *pk
+	shares := make([]*mkShare, n)
+	for j, party := range parties {
+		partyPK, share, err := party.finish(tr)
+		if err != nil {
+			return nil, nil, err
+		}
+		if pubKey != nil && !bytes.Equal(pubKey.toBytes(), partyPK.toBytes()) {
+			return nil, nil, fmt.Errorf("ERROR: party %d derived a different public key", party.index)
+		}
+		pubKey = partyPK
+		shares[j] = share
+	}
+	return pubKey, shares, nil
+}
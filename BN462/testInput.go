@@ -1,3 +1,6 @@
+//go:build !bestie
+// +build !bestie
+
 package main
 
 import "fmt"
@@ -52,6 +55,7 @@ func main() {
 	outputMessage, err := decrypt(s, id, secKey, cipher)
 	if err != nil {
 		fmt.Println("Error: ", err)
+		fmt.Println(explain(id, s))
 	} else {
 		equalMes := inputMessage.Equals(outputMessage) // test if encrypted message is same as decrypted message
 		if equalMes {
@@ -26,6 +26,8 @@ import (
 // 	// Print all KeyGen-related Parameters
 // 	secKey := keyGen(id, mk, pubKey)
 // 	printKeyGen(secKey, l)
+// 	testKeyVerification(pubKey, id, secKey)
+// 	testKeyRerandomization(pubKey, id, secKey)
 
 // 	// Create random message M in GT
 // 	inputMessage := createRandomM(pubKey)
@@ -33,12 +35,16 @@ import (
 // 	// Call Encrypt
 // 	cipher := encrypt(s, pubKey, inputMessage)
 // 	printEncrypt(cipher, inputMessage)
+// 	testHdrCheck(pubKey, s, cipher)
+// 	testHdrRerandomization(pubKey, s, id, secKey, cipher)
 
 // 	outputMessage, err := decrypt(s, id, secKey, cipher)
 // 	printDecrypt(inputMessage, outputMessage, err)
+// 	fmt.Println(explain(id, s))
 
 // 	// Check Validity of all Parameters
 // 	testValidity(pubKey, mk, inputMessage)
+// 	testGTCompression(pubKey, cipher)
 
 // }
 
@@ -96,6 +102,34 @@ func printKeyGen(secKey *sk, l int) {
 
 }
 
+// Function to verify the secret key against the public key
+func testKeyVerification(pubKey *pk, id string, secKey *sk) {
+
+	fmt.Println("\n")
+	fmt.Println("-------  Key Verification  ---------")
+
+	err := verifyKey(pubKey, id, secKey)
+	fmt.Println("Is the secret key consistent with the public key? ", err == nil)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// Function to check that a re-randomized key differs from secKey and is still consistent
+func testKeyRerandomization(pubKey *pk, id string, secKey *sk) {
+
+	fmt.Println("\n")
+	fmt.Println("-------  Key Re-randomization  ---------")
+
+	newKey, err := rerandomizeKey(pubKey, id, secKey)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Does the re-randomized key differ? ", !newKey.x0.Equals(secKey.x0) && !newKey.z.Equals(secKey.z))
+	fmt.Println("Is the re-randomized key consistent with the public key? ", verifyKey(pubKey, id, newKey) == nil)
+}
+
 // Function to print all encrypt related parameters
 func printEncrypt(cipher *hdr, inputMessage *BN462.FP12) {
 
@@ -111,6 +145,44 @@ func printEncrypt(cipher *hdr, inputMessage *BN462.FP12) {
 
 }
 
+// Function to check the header without secret key, once for its own subset and once for another one
+func testHdrCheck(pubKey *pk, s *subset, cipher *hdr) {
+
+	fmt.Println("\n")
+	fmt.Println("-------  Header Check  ---------")
+
+	err := checkHdr(pubKey, s, cipher)
+	fmt.Println("Is the header well-formed for its subset? ", err == nil)
+
+	other := []byte(s.cl)
+	if other[0] == '*' {
+		other[0] = '0'
+	} else {
+		other[0] = '*'
+	}
+	err = checkHdr(pubKey, &subset{string(other), s.rl}, cipher)
+	fmt.Println("Is the header rejected for CL", string(other), "? ", err != nil)
+}
+
+// Function to check that a re-randomized header differs from cipher and still decrypts to the same message
+func testHdrRerandomization(pubKey *pk, s *subset, id string, secKey *sk, cipher *hdr) {
+
+	fmt.Println("\n")
+	fmt.Println("-------  Header Re-randomization  ---------")
+
+	newCipher, err := rerandomizeHdr(pubKey, s, cipher)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Does the re-randomized header differ? ", !newCipher.c0.Equals(cipher.c0) && !newCipher.c1.Equals(cipher.c1))
+	fmt.Println("Is the re-randomized header well-formed? ", checkHdr(pubKey, s, newCipher) == nil)
+
+	mes, err := decrypt(s, id, secKey, cipher)
+	newMes, newErr := decrypt(s, id, secKey, newCipher)
+	fmt.Println("Do both headers decrypt to the same message? ", err == nil && newErr == nil && mes.Equals(newMes))
+}
+
 // Function to print all decrypt related parameters
 func printDecrypt(inputMessage *BN462.FP12, outputMessage *BN462.FP12, err error) {
 
@@ -137,12 +209,53 @@ func testValidity(pubkey *pk, mk *BN462.ECP, message *BN462.FP12) {
 
 	fmt.Println("\n\n")
 	fmt.Println("-------  Validity Test  ---------")
-	fmt.Println("Is h0 really in G1? ", BN462.G1member(pubkey.h0))
 	fmt.Println("Is g1 really in G1? ", BN462.G1member(pubkey.g1))
 	fmt.Println("Is g2 really in G2? ", BN462.G2member(pubkey.g2))
 	fmt.Println("Is h0 really in G1? ", BN462.G1member(pubkey.h0))
+	fmt.Println("Is k0 really in G1? ", BN462.G1member(pubkey.k0))
 	fmt.Println("Is MK a point in G1? ", BN462.G1member(mk))
 	val := BN462.GTmember(message)
 	fmt.Println("Is message a GT member? ", val)
 
+	// all elements of the public key
+	err := pubkey.Validate()
+	fmt.Println("Is the whole public key valid? ", err == nil)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+}
+
+// Function to check that C0 and omega survive compression and print the sizes
+func testGTCompression(pubkey *pk, cipher *hdr) {
+
+	fmt.Println("\n\n")
+	fmt.Println("-------  GT Compression  ---------")
+
+	for _, m := range []*BN462.FP12{cipher.c0, pubkey.omega} {
+		d, err := decompressGT(compressGT(m))
+		fmt.Println("Is the decompressed element the same? ", err == nil && d.Equals(m))
+	}
+	fmt.Println("GT element: ", gtBytes, " bytes instead of ", 2*(gtBytes-1), " bytes")
+	fmt.Println("Header: ", len(cipher.toBytes()), " bytes")
+}
+
+// function to test message validity
+func checkMessage(inputMes, outputMes *BN462.FP12) {
+	equality := inputMes.Equals(outputMes)
+	fmt.Println("Input Message is same as Output Message: ", equality)
+}
+
+// Function to create random message
+func createRandomM(pubKey *pk) *BN462.FP12 {
+	// Create message M in GT
+	q := BN462.NewBIGints(BN462.CURVE_Order)
+	rand1 := BN462.Randomnum(q, rng)
+	m1 := BN462.G1mul(pubKey.g1, rand1)
+	rand2 := BN462.Randomnum(q, rng)
+	m2 := BN462.G2mul(pubKey.g2, rand2)
+	message := BN462.Ate(m2, m1)
+	message = BN462.Fexp(message)
+
+	return message
 }
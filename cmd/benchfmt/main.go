@@ -0,0 +1,203 @@
+// Command benchfmt turns the output of the BESTIE benchmarks into CSV or JSON
+//
+// cd BN254 && go test -run '^$' -bench . -benchmem | go run ../cmd/benchfmt/main.go -format json > bench.json
+//
+// Benchmark names have the form BenchmarkOp/l=L/density=D, see benchmark_test.go
+// in the curve folders. Every unit reported next to ns/op, B/op and allocs/op,
+// such as the group operation counts, becomes a column of its own.
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// result of one benchmark
+// Density is nil for setup and keyGen
+type benchResult struct {
+	Curve       string
+	Op          string
+	L           int
+	Density     *float64 `json:",omitempty"`
+	N           int
+	NsPerOp     float64
+	AllocsPerOp float64
+	BytesPerOp  float64
+	Ops         map[string]float64 // other metrics by unit, e.g. "G1mul/op"
+}
+
+func main() {
+	if err := benchfmtMain(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Convert the benchmark output read from in as selected by args and write it to out
+func benchfmtMain(args []string, in io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("benchfmt", flag.ContinueOnError)
+	format := flags.String("format", "csv", "output format: csv or json")
+	curve := flags.String("curve", "", "curve name, taken from the pkg line of the output if empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != "csv" && *format != "json" {
+		return errors.New("unknown format " + *format)
+	}
+
+	results, units, err := parseBench(in, *curve)
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	return writeCSV(out, results, units)
+}
+
+// parse the lines of go test -bench output, units lists the extra metrics in order of appearance
+func parseBench(in io.Reader, curve string) (results []*benchResult, units []string, err error) {
+	results = []*benchResult{}
+	seen := map[string]bool{}
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "pkg: ") && curve == "" {
+			curve = path.Base(strings.TrimSpace(strings.TrimPrefix(line, "pkg: ")))
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+
+		res, err := parseName(fields[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		res.Curve = curve
+		if res.N, err = strconv.Atoi(fields[1]); err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", fields[0], err)
+		}
+		res.Ops = map[string]float64{}
+		for i := 2; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %v", fields[0], err)
+			}
+			switch unit := fields[i+1]; unit {
+			case "ns/op":
+				res.NsPerOp = v
+			case "allocs/op":
+				res.AllocsPerOp = v
+			case "B/op":
+				res.BytesPerOp = v
+			default:
+				res.Ops[unit] = v
+				if !seen[unit] {
+					seen[unit] = true
+					units = append(units, unit)
+				}
+			}
+		}
+		results = append(results, res)
+	}
+	return results, units, scanner.Err()
+}
+
+// split BenchmarkOp/l=L/density=D-P into op, l and density
+func parseName(name string) (*benchResult, error) {
+	if i := strings.LastIndex(name, "-"); i > 0 && isDigits(name[i+1:]) {
+		name = name[:i] // GOMAXPROCS suffix
+	}
+	parts := strings.Split(strings.TrimPrefix(name, "Benchmark"), "/")
+
+	op := []rune(parts[0])
+	if len(op) > 0 {
+		op[0] = unicode.ToLower(op[0])
+	}
+	res := &benchResult{Op: string(op)}
+
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%s: unexpected sub-benchmark %q", name, part)
+		}
+		switch kv[0] {
+		case "l":
+			l, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			res.L = l
+		case "density":
+			density, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			res.Density = &density
+		default:
+			return nil, fmt.Errorf("%s: unknown parameter %q", name, kv[0])
+		}
+	}
+	return res, nil
+}
+
+// check that s is a non-empty string of decimal digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// write benchmark results as CSV with a header line
+func writeCSV(out io.Writer, results []*benchResult, units []string) error {
+	w := csv.NewWriter(out)
+	w.Write(append([]string{"curve", "op", "l", "density", "n", "ns/op", "allocs/op", "B/op"}, units...))
+	for _, res := range results {
+		density := ""
+		if res.Density != nil {
+			density = strconv.FormatFloat(*res.Density, 'f', -1, 64)
+		}
+		row := []string{
+			res.Curve,
+			res.Op,
+			strconv.Itoa(res.L),
+			density,
+			strconv.Itoa(res.N),
+			formatFloat(res.NsPerOp),
+			formatFloat(res.AllocsPerOp),
+			formatFloat(res.BytesPerOp),
+		}
+		for _, unit := range units {
+			row = append(row, formatFloat(res.Ops[unit]))
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
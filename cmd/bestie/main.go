@@ -0,0 +1,113 @@
+// Command bestie runs the bestie command line tool of the curve selected with -curve
+//
+// cd BN254 && go build -tags bestie -o ../bin/bestie-BN254 . && cd ..
+// go build -o bin/bestie cmd/bestie/main.go
+// bin/bestie encrypt -curve BN254 -pk pk.bin -cl '*1****10' -rl '*****110' < movie.mp4 > movie.bstc
+//
+// Every curve folder builds its own binary bestie-<curve> (see bestie.go in the
+// curve folders), this command only picks the one for -curve, BN254 if none is
+// given, and runs it with the same arguments. It looks for that binary next to
+// itself first and then in the PATH.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// curves with a folder in this repository
+var curves = []string{"BN254", "BN462", "BLS24479", "BLS48581"}
+
+// default of -curve, as in the curve folders
+const defaultCurve = "BN254"
+
+// exit codes shared with the curve binaries
+const (
+	exitError = 1
+	exitUsage = 2
+)
+
+func main() {
+	os.Exit(dispatch(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// Run the curve binary selected by args and return its exit code
+func dispatch(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	curve, err := curveOf(args)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	bin, err := findBinary("bestie-" + curve)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	cmd := exec.Command(bin, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		return exitErr.ExitCode()
+	}
+	fmt.Fprintln(stderr, err)
+	return exitError
+}
+
+// value of the -curve flag in args, flags end at "--" as for the flag package
+func curveOf(args []string) (string, error) {
+	curve := defaultCurve
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		switch {
+		case name == arg:
+			continue // not a flag
+		case name == "curve":
+			if i+1 == len(args) {
+				return "", errors.New("flag needs an argument: -curve")
+			}
+			i++
+			curve = args[i]
+		case strings.HasPrefix(name, "curve="):
+			curve = strings.TrimPrefix(name, "curve=")
+		}
+	}
+	for _, c := range curves {
+		if c == curve {
+			return curve, nil
+		}
+	}
+	return "", fmt.Errorf("unknown curve %s, expected one of %s", curve, strings.Join(curves, ", "))
+}
+
+// path of the binary name, next to this executable or in the PATH
+func findBinary(name string) (string, error) {
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	if self, err := os.Executable(); err == nil {
+		bin := filepath.Join(filepath.Dir(self), name)
+		if info, err := os.Stat(bin); err == nil && !info.IsDir() {
+			return bin, nil
+		}
+	}
+	bin, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%s not found, build it with go build -tags bestie in its curve folder", name)
+	}
+	return bin, nil
+}